@@ -53,7 +53,12 @@ func main() {
 	}()
 
 	// Start cron server
-	svr := backup.NewCronServer(cfg)
+	runner := backup.NewRunner(backup.DefaultTransport, logging.L())
+	if runner.Quota, err = backup.NewQuota(cfg.Defaults.DailyQuota); err != nil {
+		log.Fatalf("invalid daily quota: %v", err)
+	}
+	runner.Slots = backup.NewSemaphore(cfg.Defaults.MaxProcesses)
+	svr := backup.NewCronServer(cfg, runner)
 	if err := svr.Start(ctx); err != nil {
 		log.Fatalf("failed start CronServer: %v", err)
 		return
@@ -66,6 +71,8 @@ func main() {
 	logging.L().Info("Hermes Backup Server started")
 
 	<-ctx.Done()
+	// 等待正在执行的备份响应取消并终止 rclone
+	svr.Wait()
 	logging.L().Info("Hermes Backup Server stopped")
 	if err := backup.RemovePid(); err != nil {
 		logging.L().Error("failed remove pid", zap.Error(err))
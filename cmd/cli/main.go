@@ -31,6 +31,9 @@ func main() {
 		cli.NewServerCmd(),
 		cli.NewConfigCmd(),
 		cli.NewBackupCmd(),
+		cli.NewRestoreCmd(),
+		cli.NewRemoteCmd(),
+		cli.NewHistoryCmd(),
 	)
 
 	if err := root.Execute(); err != nil {
@@ -0,0 +1,49 @@
+package backup
+
+import (
+	"fmt"
+
+	"github.com/wcx0206/hermes/internal/config"
+)
+
+// Location 是项目的一个源在某个 remote 中的备份位置
+type Location struct {
+	Remote config.RcloneRemote
+	Source config.SourcePath
+	Path   string // 后端中的路径，即 bucket/<dest>
+}
+
+func (l Location) String() string {
+	return l.Remote.Name + ":" + l.Path
+}
+
+// Locations 返回项目每个 remote、每个源的备份位置，remote 非空时只返回该 remote 的位置
+func Locations(project *config.Project, remote string) ([]Location, error) {
+	remotes, err := projectRemotes(project, remote)
+	if err != nil {
+		return nil, err
+	}
+	var locs []Location
+	for _, r := range remotes {
+		for _, src := range project.SourcePaths {
+			locs = append(locs, Location{Remote: r, Source: src, Path: destPath(r, src)})
+		}
+	}
+	return locs, nil
+}
+
+// projectRemotes 返回项目中名为 name 的 remote，name 为空时返回全部 remote
+func projectRemotes(project *config.Project, name string) ([]config.RcloneRemote, error) {
+	if len(project.RcloneRemotes) == 0 {
+		return nil, fmt.Errorf("project %s has no remotes", project.Name)
+	}
+	if name == "" {
+		return project.RcloneRemotes, nil
+	}
+	for _, remote := range project.RcloneRemotes {
+		if remote.Name == name {
+			return []config.RcloneRemote{remote}, nil
+		}
+	}
+	return nil, fmt.Errorf("remote %s not found in project %s", name, project.Name)
+}
@@ -0,0 +1,63 @@
+package backup
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+
+	"github.com/wcx0206/hermes/internal/config"
+)
+
+// catchUp 在守护进程启动时检查项目在停机期间是否错过了计划运行，按 catch_up 配置
+// 补执行一次（多次错过也只补一次）。补执行与普通触发一样经过 job 的重叠与并发控制。
+func (s *CronServer) catchUp(p *config.ScheduledProject, sched cron.Schedule, ps ProjectSchedule, job func(time.Time, string)) {
+	logger := s.jobLogger(p)
+	now := time.Now()
+	markScheduled := func() {
+		if err := s.schedule.Update(p.Key(), func(ps *ProjectSchedule) { ps.LastScheduled = now }); err != nil {
+			logger.Warn("failed to save schedule state", zap.Error(err))
+		}
+	}
+	if ps.LastScheduled.IsZero() {
+		// 首次启动，记录基准时间，此后的停机才会被补执行
+		markScheduled()
+		return
+	}
+	missed := sched.Next(ps.LastScheduled)
+	if !missed.Before(now) {
+		return
+	}
+	fields := []zap.Field{
+		zap.Time("missed", missed),
+		zap.Time("last_scheduled", ps.LastScheduled),
+		zap.Time("last_success", ps.LastSuccess),
+	}
+	if p.CatchUp == config.CatchUpSkip {
+		logger.Info("missed backup not caught up", append(fields, zap.String("catch_up", p.CatchUp))...)
+		markScheduled()
+		return
+	}
+	if p.CatchUpOlderThan > 0 && !ps.LastSuccess.IsZero() && now.Sub(ps.LastSuccess) < p.CatchUpOlderThan {
+		logger.Info("missed backup not caught up, last successful run is recent",
+			append(fields, zap.Duration("catch_up_older_than", p.CatchUpOlderThan))...)
+		markScheduled()
+		return
+	}
+	logger.Info("catching up missed backup", fields...)
+	// 备份窗口从补执行的时刻开始计算，否则错过的运行的窗口多半早已结束
+	s.cron.Schedule(&onceSchedule{}, cron.FuncJob(func() { job(time.Now(), TriggerCatchUp) }))
+}
+
+// onceSchedule 在 cron 启动后立即触发一次，之后不再触发
+type onceSchedule struct {
+	fired bool
+}
+
+func (o *onceSchedule) Next(t time.Time) time.Time {
+	if o.fired {
+		return time.Time{}
+	}
+	o.fired = true
+	return t
+}
@@ -0,0 +1,81 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/wcx0206/hermes/internal/config"
+)
+
+func TestCatchUp(t *testing.T) {
+	sched, err := cron.ParseStandard("@hourly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	tests := []struct {
+		name      string
+		catchUp   string
+		olderThan time.Duration
+		state     ProjectSchedule
+		want      bool // 是否补执行
+		marked    bool // 不补执行时是否把本次启动记为基准
+	}{
+		{"first start", config.CatchUpOnce, 0, ProjectSchedule{}, false, true},
+		{"nothing missed", config.CatchUpOnce, 0, ProjectSchedule{LastScheduled: now}, false, false},
+		{"missed", config.CatchUpOnce, 0, ProjectSchedule{LastScheduled: now.Add(-3 * time.Hour)}, true, false},
+		{"missed skip", config.CatchUpSkip, 0, ProjectSchedule{LastScheduled: now.Add(-3 * time.Hour)}, false, true},
+		{"recent success", config.CatchUpOnce, 6 * time.Hour,
+			ProjectSchedule{LastScheduled: now.Add(-3 * time.Hour), LastSuccess: now.Add(-2 * time.Hour)}, false, true},
+		{"old success", config.CatchUpOnce, 6 * time.Hour,
+			ProjectSchedule{LastScheduled: now.Add(-3 * time.Hour), LastSuccess: now.Add(-24 * time.Hour)}, true, false},
+		{"never succeeded", config.CatchUpOnce, 6 * time.Hour, ProjectSchedule{LastScheduled: now.Add(-3 * time.Hour)}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			s.cron = cron.New()
+			p := &config.ScheduledProject{Project: config.Project{
+				Name:             "docs",
+				CatchUp:          tt.catchUp,
+				CatchUpOlderThan: tt.olderThan,
+			}}
+			var triggers []string
+			s.catchUp(p, sched, tt.state, func(_ time.Time, trigger string) { triggers = append(triggers, trigger) })
+
+			entries := s.cron.Entries()
+			if got := len(entries) == 1; got != tt.want {
+				t.Fatalf("caught up = %v (%d entries), want %v", got, len(entries), tt.want)
+			}
+			if tt.want {
+				entries[0].Job.Run()
+				if len(triggers) != 1 || triggers[0] != TriggerCatchUp {
+					t.Errorf("triggers = %v, want [%s]", triggers, TriggerCatchUp)
+				}
+				return
+			}
+			// 不补执行时记录本次启动为基准，下次启动不再重复判断这段停机
+			state, err := s.schedule.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := state[p.Key()].LastScheduled
+			if marked := !got.Before(now); marked != tt.marked {
+				t.Errorf("last_scheduled = %s, marked = %v, want %v", got, marked, tt.marked)
+			}
+		})
+	}
+}
+
+func TestOnceSchedule(t *testing.T) {
+	var o onceSchedule
+	now := time.Now()
+	if got := o.Next(now); !got.Equal(now) {
+		t.Fatalf("first Next = %s, want %s", got, now)
+	}
+	if got := o.Next(now); !got.IsZero() {
+		t.Fatalf("second Next = %s, want zero", got)
+	}
+}
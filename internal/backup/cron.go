@@ -2,6 +2,11 @@ package backup
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -9,37 +14,91 @@ import (
 
 	"github.com/wcx0206/hermes/internal/config"
 	"github.com/wcx0206/hermes/internal/logging"
+	"github.com/wcx0206/hermes/internal/rclone"
 )
 
 type CronServer struct {
-	cron   *cron.Cron
-	cfg    *config.Config
-	logger *zap.Logger
+	cron     *cron.Cron
+	cfg      *config.Config
+	runner   *Runner
+	history  *History
+	schedule *ScheduleState
+	logger   *zap.Logger
+	// watchers 是 trigger: watch 项目的监听 goroutine
+	watchers sync.WaitGroup
 }
 
-func NewCronServer(cfg *config.Config) *CronServer {
+func NewCronServer(cfg *config.Config, runner *Runner) *CronServer {
 	return &CronServer{
-		cfg:    cfg,
-		cron:   cron.New(),
-		logger: logging.L(),
+		cfg:      cfg,
+		cron:     cron.New(),
+		runner:   runner,
+		history:  OpenHistory(),
+		schedule: OpenScheduleState(),
+		logger:   logging.L(),
 	}
 }
 
 func (s *CronServer) Start(ctx context.Context) error {
-	for _, project := range s.cfg.Projects {
-		p := project
-		_, err := s.cron.AddFunc(p.Cron, func() {
-			s.logger.Info("backup started", zap.String("project", p.Name))
-			now := time.Now()
-			if err := RunProject(ctx, s.cfg, &p); err != nil {
-				s.logger.Error("backup failed", zap.String("project", p.Name), zap.Error(err))
-				return
+	// 先解析所有定时任务，任何一个无效时不注册任何任务
+	for i := range s.cfg.Projects {
+		for _, p := range s.cfg.Projects[i].Scheduled() {
+			if p.Cron == "" {
+				continue
+			}
+			if _, err := p.Schedule(); err != nil {
+				return fmt.Errorf("project %s: %w", p.Key(), err)
 			}
-			cost := time.Since(now)
-			s.logger.Info("backup done", zap.String("project", p.Name), zap.Duration("cost", cost))
-		})
-		if err != nil {
-			return err
+		}
+	}
+	jobs := NewSemaphore(s.cfg.Defaults.MaxConcurrentJobs)
+	state, err := s.schedule.Load()
+	if err != nil {
+		s.logger.Warn("failed to load schedule state, skipping catch-up", zap.Error(err))
+	}
+	for i := range s.cfg.Projects {
+		project := &s.cfg.Projects[i]
+		// 同一项目的各个定时任务在进程内依次执行，而不是因项目锁被跳过
+		gate := make(chan struct{}, 1)
+		// 变化触发的运行备份整个项目，未配置 schedules 时与 cron 共用同一个 job，从而共用重叠控制
+		var watchJob func(time.Time, string)
+		for _, p := range project.Scheduled() {
+			job := s.guard(ctx, &p, gate, jobs, func(scheduled time.Time, trigger string) { s.runJob(ctx, &p, scheduled, trigger) })
+			if p.ScheduleName == "" {
+				watchJob = job
+			}
+			if p.Cron == "" {
+				// 没有 cron 的项目只能手动备份或由文件变化触发
+				s.jobLogger(&p).Info("backup not scheduled, no cron", zap.String("trigger", p.Trigger))
+				continue
+			}
+			sched, err := p.Schedule()
+			if err != nil {
+				return fmt.Errorf("project %s: %w", p.Key(), err)
+			}
+			sched, offset := delaySchedule(sched, p.Key(), p.Stagger, p.Jitter)
+			s.cron.Schedule(sched, cron.FuncJob(func() { job(time.Now(), TriggerCron) }))
+			s.jobLogger(&p).Info("backup scheduled",
+				zap.String("cron", p.Cron),
+				zap.String("mode", p.Mode),
+				zap.Int("remotes", len(p.RcloneRemotes)),
+				zap.Stringer("location", p.Location()),
+				zap.Duration("stagger_offset", offset),
+				zap.Duration("jitter", p.Jitter))
+			if state != nil {
+				s.catchUp(&p, sched, state[p.Key()], job)
+			}
+		}
+		if project.Trigger == config.TriggerWatch {
+			if watchJob == nil {
+				p := config.ScheduledProject{Project: *project}
+				watchJob = s.guard(ctx, &p, gate, jobs, func(scheduled time.Time, trigger string) { s.runJob(ctx, &p, scheduled, trigger) })
+			}
+			s.watchers.Add(1)
+			go func() {
+				defer s.watchers.Done()
+				s.watch(ctx, project, watchJob)
+			}()
 		}
 	}
 	s.cron.Start()
@@ -50,3 +109,215 @@ func (s *CronServer) Start(ctx context.Context) error {
 	}()
 	return nil
 }
+
+// guard 包装项目的定时任务：同一任务上一次运行尚未结束时按 overlap 配置跳过，
+// 或排队等待（最多排队一次，更多的触发被跳过）；同一项目的其他任务（其他 schedules 条目
+// 或文件变化触发）正在运行时等待 gate；jobs 限制整个守护进程同时运行的任务数。
+// 返回的函数接收计划执行的时间与触发方式，每次调用都会记录为任务最近一次计划运行。
+func (s *CronServer) guard(ctx context.Context, p *config.ScheduledProject, gate chan struct{}, jobs *Semaphore, run func(scheduled time.Time, trigger string)) func(time.Time, string) {
+	running := make(chan struct{}, 1)
+	var queued atomic.Bool
+	logger := s.jobLogger(p)
+	return func(scheduled time.Time, trigger string) {
+		if err := s.schedule.Update(p.Key(), func(ps *ProjectSchedule) { ps.LastScheduled = time.Now() }); err != nil {
+			logger.Warn("failed to save schedule state", zap.Error(err))
+		}
+		select {
+		case running <- struct{}{}:
+		default:
+			if p.Overlap != config.OverlapQueue || !queued.CompareAndSwap(false, true) {
+				logger.Warn("backup skipped, previous run still running", zap.String("overlap", p.Overlap))
+				return
+			}
+			logger.Info("backup delayed, previous run still running")
+			select {
+			case running <- struct{}{}:
+				queued.Store(false)
+			case <-ctx.Done():
+				queued.Store(false)
+				return
+			}
+		}
+		defer func() { <-running }()
+
+		select {
+		case gate <- struct{}{}:
+		default:
+			logger.Info("backup delayed, another job of the project is running")
+			select {
+			case gate <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		defer func() { <-gate }()
+
+		if !jobs.TryAcquire() {
+			logger.Info("backup delayed, max_concurrent_jobs reached", zap.Int("max_concurrent_jobs", s.cfg.Defaults.MaxConcurrentJobs))
+			if err := jobs.Acquire(ctx); err != nil {
+				return
+			}
+		}
+		defer jobs.Release()
+		run(scheduled, trigger)
+	}
+}
+
+// runJob 执行一次定时备份，scheduled 为计划执行的时间，用于计算备份窗口
+func (s *CronServer) runJob(ctx context.Context, p *config.ScheduledProject, scheduled time.Time, trigger string) {
+	logger := s.jobLogger(p)
+	if deadline, ok := p.WindowDeadline(scheduled); ok {
+		if !time.Now().Before(deadline) {
+			logger.Warn("backup skipped, backup window closed", zap.Time("window_end", deadline))
+			return
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+	// 项目可能正被 hermes backup run 在另一个进程中备份
+	lock, err := LockProject(ctx, p.Name, false)
+	var locked *LockedError
+	if errors.As(err, &locked) {
+		switch {
+		case locked.Holder.PID == os.Getpid():
+			// gate 使进程内同一项目的任务依次执行，锁只会短暂地被本进程的上一个任务持有
+		case p.Overlap != config.OverlapQueue:
+			logger.Warn("backup skipped, project locked by another process", zap.Stringer("holder", locked.Holder))
+			return
+		default:
+			logger.Info("backup delayed, project locked by another process", zap.Stringer("holder", locked.Holder))
+		}
+		lock, err = LockProject(ctx, p.Name, true)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		logger.Warn("backup skipped, backup window closed while waiting for project lock")
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if err != nil {
+		logger.Error("backup failed", zap.Error(err))
+		return
+	}
+	defer func() {
+		if err := lock.Unlock(); err != nil {
+			logger.Warn("failed to release project lock", zap.Error(err))
+		}
+	}()
+
+	logger.Info("backup started", zap.String("trigger", trigger))
+	now := time.Now()
+	result, err := s.runner.RunProject(ctx, s.cfg, &p.Project)
+	s.logUnits(logger, result)
+	rec := NewHistoryRecord(p.Name, trigger, now, result, err)
+	rec.Schedule = p.ScheduleName
+	s.record(rec)
+	if err == nil {
+		if err := s.schedule.Update(p.Key(), func(ps *ProjectSchedule) { ps.LastSuccess = now }); err != nil {
+			logger.Warn("failed to save schedule state", zap.Error(err))
+		}
+	}
+	if errors.Is(err, context.Canceled) {
+		logger.Warn("backup cancelled", zap.Duration("cost", time.Since(now)))
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		logger.Warn("backup timed out",
+			zap.Duration("cost", time.Since(now)),
+			zap.Duration("timeout", p.Timeout),
+			zap.String("window_end", p.WindowEnd),
+			zap.Int("failed_units", result.Failed()),
+			zap.Int("units", len(result.Units)))
+		return
+	}
+	if errors.Is(err, ErrQuotaExhausted) {
+		logger.Warn("backup stopped by daily quota", zap.Error(err))
+		return
+	}
+	if err != nil {
+		logger.Error("backup failed", append(verifyFields(result.Verify),
+			zap.Int("failed_units", result.Failed()),
+			zap.Int("units", len(result.Units)),
+			zap.Duration("cost", time.Since(now)),
+			zap.Error(err))...)
+		return
+	}
+	cost := time.Since(now)
+	logger.Info("backup done", append([]zap.Field{
+		zap.Duration("cost", cost),
+		zap.Int64("bytes", result.Stats.Bytes),
+		zap.Int64("transfers", result.Stats.Transfers),
+		zap.Int64("checks", result.Stats.Checks),
+		zap.Int64("deletes", result.Stats.Deletes),
+		zap.Int64("errors", result.Stats.Errors),
+		zap.Int64("archived", result.Archived),
+	}, verifyFields(result.Verify)...)...)
+}
+
+// verifyFields 返回校验结果的日志字段，未执行校验时为空
+func verifyFields(v VerifyResult) []zap.Field {
+	if verifyRecord(v) == nil {
+		return nil
+	}
+	return []zap.Field{
+		zap.Int("verified", v.Checked),
+		zap.Int("verify_missing", v.Missing),
+		zap.Int("verify_differ", v.Differ),
+		zap.Int("verify_extra", v.Extra),
+	}
+}
+
+// record 保存运行记录，并按 history_keep_days 清理旧记录
+func (s *CronServer) record(rec HistoryRecord) {
+	if err := s.history.Append(rec); err != nil {
+		s.logger.Warn("failed to record run history", zap.String("project", rec.Project), zap.Error(err))
+		return
+	}
+	if days := s.cfg.Defaults.HistoryKeepDays; days > 0 {
+		if _, err := s.history.Prune(time.Now().AddDate(0, 0, -days)); err != nil {
+			s.logger.Warn("failed to prune run history", zap.Error(err))
+		}
+	}
+}
+
+// logUnits 为每个 (源, remote) 单元记录一条日志
+func (s *CronServer) logUnits(logger *zap.Logger, result *RunResult) {
+	for _, u := range result.Units {
+		fields := []zap.Field{
+			zap.String("remote", u.Remote),
+			zap.String("source", u.Source),
+			zap.String("dest", u.Dest),
+			zap.String("status", u.Status),
+			zap.Duration("duration", u.Duration),
+			zap.Int64("bytes", u.Stats.Bytes),
+			zap.Int64("transfers", u.Stats.Transfers),
+		}
+		fields = append(fields, verifyFields(u.Verify)...)
+		if u.Status == UnitCancelled {
+			logger.Warn("backup unit cancelled", fields...)
+			continue
+		}
+		if u.Err != nil {
+			fields = append(fields, zap.String("category", string(rclone.Classify(u.Err))), zap.Error(u.Err))
+			logger.Error("backup unit failed", fields...)
+			continue
+		}
+		logger.Info("backup unit finished", fields...)
+	}
+}
+
+// jobLogger 返回带有项目与 schedules 条目名称的 logger
+func (s *CronServer) jobLogger(p *config.ScheduledProject) *zap.Logger {
+	if p.ScheduleName == "" {
+		return s.logger.With(zap.String("project", p.Name))
+	}
+	return s.logger.With(zap.String("project", p.Name), zap.String("schedule", p.ScheduleName))
+}
+
+// Wait 阻塞直到所有正在执行的备份任务退出，应在 Start 的 ctx 取消后调用
+func (s *CronServer) Wait() {
+	<-s.cron.Stop().Done()
+	s.watchers.Wait()
+}
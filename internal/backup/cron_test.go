@@ -0,0 +1,210 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/wcx0206/hermes/internal/config"
+)
+
+func TestLogUnitsLevels(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	s := &CronServer{logger: zap.New(core)}
+	s.logUnits(s.logger, &RunResult{Units: []UnitResult{
+		{Remote: "r", Source: "/a", Status: UnitOK},
+		{Remote: "r", Source: "/b", Status: UnitCancelled},
+		{Remote: "r", Source: "/c", Status: UnitFailed, Err: errors.New("boom")},
+	}})
+	want := []struct {
+		level zapcore.Level
+		msg   string
+	}{
+		{zapcore.InfoLevel, "backup unit finished"},
+		{zapcore.WarnLevel, "backup unit cancelled"},
+		{zapcore.ErrorLevel, "backup unit failed"},
+	}
+	entries := logs.All()
+	if len(entries) != len(want) {
+		t.Fatalf("logged %d entries, want %d", len(entries), len(want))
+	}
+	for i, e := range entries {
+		if e.Level != want[i].level || e.Message != want[i].msg {
+			t.Errorf("entry %d = %s %q, want %s %q", i, e.Level, e.Message, want[i].level, want[i].msg)
+		}
+	}
+}
+
+func newTestServer(t *testing.T) *CronServer {
+	return &CronServer{
+		cfg:      &config.Config{},
+		history:  &History{path: filepath.Join(t.TempDir(), "hermes-history.jsonl")},
+		schedule: &ScheduleState{path: filepath.Join(t.TempDir(), "hermes-schedule.json")},
+		logger:   zap.NewNop(),
+	}
+}
+
+// startBlocked 在 goroutine 中调用 job，并等待 run 开始执行
+func startBlocked(t *testing.T, job func(time.Time, string), started <-chan struct{}) {
+	t.Helper()
+	go job(time.Now(), TriggerCron)
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("job did not start")
+	}
+}
+
+func TestGuardOverlap(t *testing.T) {
+	for _, overlap := range []string{config.OverlapSkip, config.OverlapQueue} {
+		t.Run(overlap, func(t *testing.T) {
+			s := newTestServer(t)
+			p := &config.ScheduledProject{Project: config.Project{Name: "guard-" + overlap, Overlap: overlap}}
+			started, release := make(chan struct{}, 4), make(chan struct{})
+			var runs atomic.Int32
+			job := s.guard(context.Background(), p, make(chan struct{}, 1), nil, func(time.Time, string) {
+				runs.Add(1)
+				started <- struct{}{}
+				<-release
+			})
+			startBlocked(t, job, started)
+
+			done := make(chan struct{})
+			go func() {
+				job(time.Now(), TriggerCron)
+				close(done)
+			}()
+			if overlap == config.OverlapSkip {
+				select {
+				case <-done:
+				case <-time.After(2 * time.Second):
+					t.Fatal("overlapping run was not skipped")
+				}
+				close(release)
+				if n := runs.Load(); n != 1 {
+					t.Errorf("runs = %d, want 1", n)
+				}
+				return
+			}
+			// 排队中的运行之外的触发被跳过
+			time.Sleep(50 * time.Millisecond)
+			job(time.Now(), TriggerCron)
+			close(release)
+			<-done
+			if n := runs.Load(); n != 2 {
+				t.Errorf("runs = %d, want 2", n)
+			}
+		})
+	}
+}
+
+func TestGuardMaxConcurrentJobs(t *testing.T) {
+	s := newTestServer(t)
+	jobs := NewSemaphore(1)
+	started, release := make(chan string, 2), make(chan struct{})
+	newJob := func(name string) func(time.Time, string) {
+		p := &config.ScheduledProject{Project: config.Project{Name: name}}
+		return s.guard(context.Background(), p, make(chan struct{}, 1), jobs, func(time.Time, string) {
+			started <- name
+			<-release
+		})
+	}
+	a, b := newJob("a"), newJob("b")
+	go a(time.Now(), TriggerCron)
+	if got := <-started; got != "a" {
+		t.Fatalf("started %s, want a", got)
+	}
+	done := make(chan struct{})
+	go func() {
+		b(time.Now(), TriggerCron)
+		close(done)
+	}()
+	// 另一个项目的任务等待名额，而不是被跳过
+	select {
+	case got := <-started:
+		t.Fatalf("%s started beyond max_concurrent_jobs", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+	release <- struct{}{}
+	if got := <-started; got != "b" {
+		t.Fatalf("started %s, want b", got)
+	}
+	close(release)
+	<-done
+}
+
+func TestStartSkipsProjectsWithoutCron(t *testing.T) {
+	s := newTestServer(t)
+	s.cron = cron.New()
+	s.cfg = &config.Config{Projects: []config.Project{
+		{Name: "manual"},
+		{Name: "nightly", Cron: "0 3 * * *"},
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("start with a project without cron: %v", err)
+	}
+	if n := len(s.cron.Entries()); n != 1 {
+		t.Fatalf("%d jobs scheduled, want only the project with a cron", n)
+	}
+
+	s = newTestServer(t)
+	s.cron = cron.New()
+	s.cfg = &config.Config{Projects: []config.Project{
+		{Name: "nightly", Cron: "0 3 * * *"},
+		{Name: "broken", Cron: "61 * * * *"},
+	}}
+	if err := s.Start(ctx); err == nil {
+		t.Fatal("start with an invalid cron succeeded")
+	}
+	if n := len(s.cron.Entries()); n != 0 {
+		t.Errorf("%d jobs scheduled although a cron is invalid, want none", n)
+	}
+}
+
+func TestGuardSerializesJobsOfProject(t *testing.T) {
+	s := newTestServer(t)
+	gate := make(chan struct{}, 1)
+	var running, maxRunning atomic.Int32
+	started, release := make(chan struct{}, 2), make(chan struct{})
+	run := func(time.Time, string) {
+		n := running.Add(1)
+		if n > maxRunning.Load() {
+			maxRunning.Store(n)
+		}
+		started <- struct{}{}
+		<-release
+		running.Add(-1)
+	}
+	hourly := s.guard(context.Background(), &config.ScheduledProject{ScheduleName: "hourly", Project: config.Project{Name: "gate"}}, gate, nil, run)
+	nightly := s.guard(context.Background(), &config.ScheduledProject{ScheduleName: "nightly", Project: config.Project{Name: "gate"}}, gate, nil, run)
+	startBlocked(t, hourly, started)
+
+	// 同一项目的另一个条目等待而不是被跳过
+	done := make(chan struct{})
+	go func() {
+		nightly(time.Now(), TriggerCron)
+		close(done)
+	}()
+	select {
+	case <-started:
+		t.Fatal("second job of the project ran concurrently")
+	case <-time.After(100 * time.Millisecond):
+	}
+	release <- struct{}{}
+	<-started
+	close(release)
+	<-done
+	if n := maxRunning.Load(); n != 1 {
+		t.Errorf("max concurrent runs = %d, want 1", n)
+	}
+}
@@ -0,0 +1,76 @@
+package backup
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/wcx0206/hermes/internal/config"
+	"github.com/wcx0206/hermes/internal/rclone"
+)
+
+// Diff 是本地源相对于 remote 中备份的差异，即下一次 sync 将要执行的操作
+type Diff struct {
+	Remote    string   `json:"remote"`
+	Source    string   `json:"source"`
+	Dest      string   `json:"dest"`
+	New       []string `json:"new"`     // 仅存在于本地，将被上传
+	Changed   []string `json:"changed"` // 两端不一致，将被覆盖
+	Deleted   []string `json:"deleted"` // 本地已删除，sync 模式下将从 remote 删除
+	Unchanged int      `json:"unchanged"`
+}
+
+// planSync 以 sync 判断变化的方式（修改时间，或启用 checksum 时的哈希）与过滤规则比较两端
+func planSync(ctx context.Context, client rclone.Transport, remote config.RcloneRemote, src config.SourcePath, dst string) (*rclone.CheckResult, error) {
+	compare := rclone.CompareModTime
+	if remote.Options.UseChecksum() {
+		compare = rclone.CompareHash
+	}
+	return client.Check(ctx, src.Path, dst, rclone.CheckOptions{
+		Include: remote.Options.Include,
+		Exclude: remote.Options.Exclude,
+		Compare: compare,
+	})
+}
+
+// DiffProject 比较项目的每个源与 remote 中的备份，remote 为空时比较所有 remote
+func (r *Runner) DiffProject(ctx context.Context, cfg *config.Config, project *config.Project, remote string) ([]Diff, error) {
+	locs, err := Locations(project, remote)
+	if err != nil {
+		return nil, err
+	}
+	logger := r.Logger.With(zap.String("project", project.Name))
+	clients := make(map[string]rclone.Transport)
+	var diffs []Diff
+	for _, loc := range locs {
+		client, ok := clients[loc.Remote.Name]
+		if !ok {
+			if client, err = r.NewTransport(cfg, loc.Remote, logger); err != nil {
+				return diffs, err
+			}
+			clients[loc.Remote.Name] = client
+		}
+		plan, err := planSync(ctx, client, loc.Remote, loc.Source, loc.Path)
+		if err != nil {
+			return diffs, err
+		}
+		diffs = append(diffs, Diff{
+			Remote:    loc.Remote.Name,
+			Source:    loc.Source.Path,
+			Dest:      loc.Path,
+			New:       orEmpty(plan.Missing),
+			Changed:   orEmpty(plan.Differ),
+			Deleted:   orEmpty(plan.Extra),
+			Unchanged: plan.Same,
+		})
+	}
+	return diffs, nil
+}
+
+// orEmpty 使 JSON 输出中没有差异的列表为 [] 而不是 null
+func orEmpty(paths []string) []string {
+	if paths == nil {
+		return []string{}
+	}
+	return paths
+}
@@ -0,0 +1,56 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDiffProject(t *testing.T) {
+	root, bucket := t.TempDir(), t.TempDir()
+	data := filepath.Join(root, "data")
+	writeFiles(t, data, "same", "changed", "deleted", "skip.tmp")
+	cfg, project := localProject(t, "sync", bucket, []string{data}, "    options: {exclude: [\"*.tmp\"]}\n")
+	runner := NewRunner(nil, nil)
+	if _, err := runner.RunProject(context.Background(), cfg, project); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFiles(t, data, "new", "new.tmp")
+	// 大小相同、修改时间不同的文件也算修改，与 sync 的判断方式一致
+	changed := filepath.Join(data, "changed")
+	if err := os.WriteFile(changed, []byte("CHANGED"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(changed, time.Now().Add(time.Hour), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(data, "deleted")); err != nil {
+		t.Fatal(err)
+	}
+
+	diffs, err := runner.DiffProject(context.Background(), cfg, project, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 {
+		t.Fatalf("got %d diffs, want 1", len(diffs))
+	}
+	d := diffs[0]
+	if d.Remote != "disk" || d.Source != data || d.Dest != filepath.Join(bucket, "data") {
+		t.Errorf("diff location = %s %s -> %s", d.Remote, d.Source, d.Dest)
+	}
+	if !slices.Equal(d.New, []string{"new"}) || !slices.Equal(d.Changed, []string{"changed"}) ||
+		!slices.Equal(d.Deleted, []string{"deleted"}) || d.Unchanged != 1 {
+		t.Errorf("diff = %+v", d)
+	}
+
+	// diff 不修改远端
+	assertFiles(t, bucket, "data/same", "data/changed", "data/deleted")
+	if _, err := runner.DiffProject(context.Background(), cfg, project, "missing"); err == nil {
+		t.Error("diff against an unknown remote succeeded")
+	}
+}
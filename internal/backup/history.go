@@ -0,0 +1,268 @@
+package backup
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+	"time"
+)
+
+// 触发备份的方式
+const (
+	TriggerCron    = "cron"
+	TriggerCatchUp = "catch-up" // 守护进程重启后补执行停机期间错过的运行
+	TriggerWatch   = "watch"    // 源路径变化后执行
+	TriggerManual  = "manual"
+)
+
+// HistoryRecord 的状态
+const (
+	RunOK        = "ok"
+	RunFailed    = "failed"
+	RunTimedOut  = "timeout"
+	RunCancelled = "cancelled"
+	RunQuota     = "quota" // 因每日配额用尽停止
+)
+
+// HistoryRecord 是一次项目备份的记录
+type HistoryRecord struct {
+	ID        string    `json:"id"`
+	Project   string    `json:"project"`
+	Trigger   string    `json:"trigger"`
+	Schedule  string    `json:"schedule,omitempty"` // 触发运行的 schedules 条目
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Status    string    `json:"status"`
+	Bytes     int64     `json:"bytes"`
+	Transfers int64     `json:"transfers"`
+	Error     string    `json:"error,omitempty"`
+	// Verify 是启用 verify 时所有单元校验结果的汇总
+	Verify *VerifyResult `json:"verify,omitempty"`
+	Units  []HistoryUnit `json:"units"`
+}
+
+func (r *HistoryRecord) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// HistoryUnit 是一次备份中一个 (源, remote) 的结果
+type HistoryUnit struct {
+	Remote    string        `json:"remote"`
+	Source    string        `json:"source"`
+	Dest      string        `json:"dest"`
+	Status    string        `json:"status"`
+	Seconds   float64       `json:"seconds"`
+	Bytes     int64         `json:"bytes"`
+	Transfers int64         `json:"transfers"`
+	Error     string        `json:"error,omitempty"`
+	Verify    *VerifyResult `json:"verify,omitempty"`
+}
+
+// NewHistoryRecord 根据 RunProject 的结果创建记录，result 可以为 nil
+func NewHistoryRecord(project, trigger string, start time.Time, result *RunResult, err error) HistoryRecord {
+	rec := HistoryRecord{
+		ID:      newRunID(start),
+		Project: project,
+		Trigger: trigger,
+		Start:   start,
+		End:     time.Now(),
+		Status:  RunStatus(err),
+		Units:   []HistoryUnit{},
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	if result == nil {
+		return rec
+	}
+	rec.Bytes = result.Stats.Bytes
+	rec.Transfers = result.Stats.Transfers
+	rec.Verify = verifyRecord(result.Verify)
+	for _, u := range result.Units {
+		hu := HistoryUnit{
+			Remote:    u.Remote,
+			Source:    u.Source,
+			Dest:      u.Dest,
+			Status:    u.Status,
+			Seconds:   u.Duration.Seconds(),
+			Bytes:     u.Stats.Bytes,
+			Transfers: u.Stats.Transfers,
+			Verify:    verifyRecord(u.Verify),
+		}
+		if u.Err != nil {
+			hu.Error = u.Err.Error()
+		}
+		rec.Units = append(rec.Units, hu)
+	}
+	return rec
+}
+
+// verifyRecord 在执行了校验时返回 v 的副本，否则返回 nil
+func verifyRecord(v VerifyResult) *VerifyResult {
+	if v.Checked == 0 && v.OK() {
+		return nil
+	}
+	return &v
+}
+
+// RunStatus 返回 RunProject 的错误对应的记录状态
+func RunStatus(err error) string {
+	switch {
+	case err == nil:
+		return RunOK
+	case errors.Is(err, context.Canceled):
+		return RunCancelled
+	case errors.Is(err, context.DeadlineExceeded):
+		return RunTimedOut
+	case errors.Is(err, ErrQuotaExhausted):
+		return RunQuota
+	}
+	return RunFailed
+}
+
+func newRunID(start time.Time) string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return start.UTC().Format(versionLayout) + "-" + hex.EncodeToString(b)
+}
+
+// History 是保存在 pid 文件旁的 JSON Lines 运行记录，由守护进程与 CLI 共享，
+// 读写时对文件加 flock
+type History struct {
+	path string
+}
+
+func OpenHistory() *History {
+	return &History{path: stateFilePath("hermes-history.jsonl")}
+}
+
+// HistoryFilter 是 Query 的条件，零值表示不过滤
+type HistoryFilter struct {
+	Project string
+	Since   time.Time
+	Failed  bool // 只返回状态不是 ok 的记录
+}
+
+func (h *History) Append(rec HistoryRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open history: %w", err)
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("lock history: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write history: %w", err)
+	}
+	return nil
+}
+
+// Query 按时间顺序返回满足条件的记录
+func (h *History) Query(filter HistoryFilter) ([]HistoryRecord, error) {
+	all, err := h.read()
+	if err != nil {
+		return nil, err
+	}
+	var recs []HistoryRecord
+	for _, rec := range all {
+		if filter.Project != "" && rec.Project != filter.Project {
+			continue
+		}
+		if !filter.Since.IsZero() && rec.Start.Before(filter.Since) {
+			continue
+		}
+		if filter.Failed && rec.Status == RunOK {
+			continue
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+// Prune 删除开始时间早于 before 的记录，返回删除的条数
+func (h *History) Prune(before time.Time) (int, error) {
+	f, err := os.OpenFile(h.path, os.O_RDWR, 0o644)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("open history: %w", err)
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return 0, fmt.Errorf("lock history: %w", err)
+	}
+	all, err := decodeHistory(f)
+	if err != nil {
+		return 0, err
+	}
+	var keep []byte
+	pruned := 0
+	for _, rec := range all {
+		if rec.Start.Before(before) {
+			pruned++
+			continue
+		}
+		data, err := json.Marshal(rec)
+		if err != nil {
+			return 0, err
+		}
+		keep = append(append(keep, data...), '\n')
+	}
+	if pruned == 0 {
+		return 0, nil
+	}
+	// 在持有锁的同一个文件上原地重写，其他进程的 Append 会等待锁释放
+	if err := f.Truncate(0); err != nil {
+		return 0, fmt.Errorf("rewrite history: %w", err)
+	}
+	if _, err := f.WriteAt(keep, 0); err != nil {
+		return 0, fmt.Errorf("rewrite history: %w", err)
+	}
+	return pruned, nil
+}
+
+func (h *History) read() ([]HistoryRecord, error) {
+	f, err := os.Open(h.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open history: %w", err)
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_SH); err != nil {
+		return nil, fmt.Errorf("lock history: %w", err)
+	}
+	return decodeHistory(f)
+}
+
+// decodeHistory 逐行解析记录，跳过无法解析的行（例如写入中断留下的半行）
+func decodeHistory(f *os.File) ([]HistoryRecord, error) {
+	var recs []HistoryRecord
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		var rec HistoryRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			continue
+		}
+		recs = append(recs, rec)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	return recs, nil
+}
@@ -0,0 +1,131 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestHistory(t *testing.T) *History {
+	return &History{path: filepath.Join(t.TempDir(), "hermes-history.jsonl")}
+}
+
+func TestHistoryAppendQuery(t *testing.T) {
+	h := newTestHistory(t)
+	if recs, err := h.Query(HistoryFilter{}); err != nil || len(recs) != 0 {
+		t.Fatalf("query before any run = %v, %v, want empty", recs, err)
+	}
+
+	base := time.Date(2024, 5, 1, 3, 0, 0, 0, time.UTC)
+	runs := []struct {
+		project string
+		start   time.Time
+		err     error
+	}{
+		{"docs", base, nil},
+		{"photos", base.Add(time.Hour), errors.New("remote unreachable")},
+		{"docs", base.Add(24 * time.Hour), context.DeadlineExceeded},
+		{"docs", base.Add(48 * time.Hour), nil},
+	}
+	for _, r := range runs {
+		rec := NewHistoryRecord(r.project, TriggerCron, r.start, nil, r.err)
+		if err := h.Append(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter HistoryFilter
+		want   []string // 按顺序期望的 项目/状态
+	}{
+		{"all", HistoryFilter{}, []string{"docs/ok", "photos/failed", "docs/timeout", "docs/ok"}},
+		{"project", HistoryFilter{Project: "docs"}, []string{"docs/ok", "docs/timeout", "docs/ok"}},
+		{"since", HistoryFilter{Since: base.Add(time.Hour)}, []string{"photos/failed", "docs/timeout", "docs/ok"}},
+		{"failed", HistoryFilter{Failed: true}, []string{"photos/failed", "docs/timeout"}},
+		{"combined", HistoryFilter{Project: "docs", Since: base.Add(time.Hour), Failed: true}, []string{"docs/timeout"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recs, err := h.Query(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, rec := range recs {
+				got = append(got, rec.Project+"/"+rec.Status)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	recs, _ := h.Query(HistoryFilter{Project: "photos"})
+	if recs[0].Error != "remote unreachable" || !recs[0].Start.Equal(base.Add(time.Hour)) {
+		t.Errorf("record not round-tripped: %+v", recs[0])
+	}
+}
+
+func TestHistorySkipsTruncatedLine(t *testing.T) {
+	h := newTestHistory(t)
+	if err := h.Append(NewHistoryRecord("docs", TriggerManual, time.Now(), nil, nil)); err != nil {
+		t.Fatal(err)
+	}
+	// 模拟写入中断留下的半行
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"id":"broken","proj`)
+	f.Close()
+
+	recs, err := h.Query(HistoryFilter{})
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("query = %d records, %v, want 1", len(recs), err)
+	}
+}
+
+func TestHistoryPrune(t *testing.T) {
+	h := newTestHistory(t)
+	if n, err := h.Prune(time.Now()); err != nil || n != 0 {
+		t.Fatalf("prune without history = %d, %v, want 0, nil", n, err)
+	}
+
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for day := range 5 {
+		if err := h.Append(NewHistoryRecord("docs", TriggerCron, base.AddDate(0, 0, day), nil, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n, err := h.Prune(base.AddDate(0, 0, 3))
+	if err != nil || n != 3 {
+		t.Fatalf("prune = %d, %v, want 3", n, err)
+	}
+	recs, err := h.Query(HistoryFilter{})
+	if err != nil || len(recs) != 2 {
+		t.Fatalf("after prune: %d records, %v, want 2", len(recs), err)
+	}
+	if !recs[0].Start.Equal(base.AddDate(0, 0, 3)) {
+		t.Errorf("oldest kept = %s, want %s", recs[0].Start, base.AddDate(0, 0, 3))
+	}
+
+	// 重写后仍可继续追加
+	if err := h.Append(NewHistoryRecord("docs", TriggerCron, base.AddDate(0, 0, 5), nil, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if recs, _ := h.Query(HistoryFilter{}); len(recs) != 3 {
+		t.Errorf("after append: %d records, want 3", len(recs))
+	}
+	if n, err := h.Prune(base); err != nil || n != 0 {
+		t.Errorf("second prune = %d, %v, want 0", n, err)
+	}
+}
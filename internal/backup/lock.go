@@ -0,0 +1,107 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// 等待项目锁时检查锁是否释放的间隔
+const lockPollInterval = time.Second
+
+// LockInfo 记录项目锁的持有者，写入锁文件
+type LockInfo struct {
+	PID     int       `json:"pid"`
+	Started time.Time `json:"started"`
+	Command string    `json:"command"`
+}
+
+func (i LockInfo) String() string {
+	if i.PID == 0 {
+		return "an unknown process"
+	}
+	return fmt.Sprintf("pid %d (%s) since %s", i.PID, i.Command, i.Started.Format(time.DateTime))
+}
+
+// LockedError 表示项目正被另一个进程备份
+type LockedError struct {
+	Project string
+	Holder  LockInfo
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("project %s is being backed up by %s", e.Project, e.Holder)
+}
+
+// ProjectLock 是基于 flock 的项目锁，守护进程与 CLI 在备份项目前都需要持有，
+// 避免两个进程同时向同一 bucket 写入。进程退出时锁由内核自动释放。
+type ProjectLock struct {
+	file *os.File
+}
+
+var lockNameReplacer = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+func lockFilePath(project string) string {
+	return stateFilePath("hermes-" + lockNameReplacer.ReplaceAllString(project, "_") + ".lock")
+}
+
+// LockProject 获取项目锁。锁被占用时，wait 为 false 返回 *LockedError，
+// 否则等待直到获得锁或 ctx 结束。
+func LockProject(ctx context.Context, project string, wait bool) (*ProjectLock, error) {
+	f, err := os.OpenFile(lockFilePath(project), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file for project %s: %w", project, err)
+	}
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			f.Close()
+			return nil, fmt.Errorf("lock project %s: %w", project, err)
+		}
+		if !wait {
+			holder := readLockInfo(f)
+			f.Close()
+			return nil, &LockedError{Project: project, Holder: holder}
+		}
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+	info := LockInfo{PID: os.Getpid(), Started: time.Now(), Command: strings.Join(os.Args, " ")}
+	data, _ := json.Marshal(info)
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt(data, 0)
+	}
+	return &ProjectLock{file: f}, nil
+}
+
+// Unlock 清空持有者信息并释放锁
+func (l *ProjectLock) Unlock() error {
+	_ = l.file.Truncate(0)
+	err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// readLockInfo 读取持有者信息，持有者刚获得锁尚未写入时返回零值
+func readLockInfo(f *os.File) LockInfo {
+	var info LockInfo
+	data := make([]byte, 4096)
+	n, _ := f.ReadAt(data, 0)
+	_ = json.Unmarshal(data[:n], &info)
+	return info
+}
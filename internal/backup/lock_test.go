@@ -0,0 +1,37 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLockProjectConflict(t *testing.T) {
+	lock, err := LockProject(context.Background(), t.Name(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LockProject(context.Background(), t.Name(), false)
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.Holder.PID != os.Getpid() {
+		t.Fatalf("err = %v, want *LockedError held by this process", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := LockProject(ctx, t.Name(), true); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("waiting for a held lock: err = %v, want deadline exceeded", err)
+	}
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	lock, err = LockProject(context.Background(), t.Name(), false)
+	if err != nil {
+		t.Fatalf("lock after unlock: %v", err)
+	}
+	lock.Unlock()
+	os.Remove(lockFilePath(t.Name()))
+}
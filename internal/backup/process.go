@@ -8,11 +8,16 @@ import (
 )
 
 func getBackupPidFilePath() string {
+	return stateFilePath("hermes-backup.pid")
+}
+
+// stateFilePath 返回与 pid 文件位于同一目录下的状态文件路径
+func stateFilePath(name string) string {
 	execPath, err := os.Executable()
 	if err != nil {
-		return "~/.cache/hermes/hermes-backup.pid"
+		return filepath.Join("~/.cache/hermes", name)
 	}
-	return filepath.Join(filepath.Dir(execPath), "hermes-backup.pid")
+	return filepath.Join(filepath.Dir(execPath), name)
 }
 
 func GetPid() (int, error) {
@@ -2,23 +2,303 @@ package backup
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
 
 	"github.com/wcx0206/hermes/internal/config"
 	"github.com/wcx0206/hermes/internal/rclone"
 )
 
-func RunProject(ctx context.Context, cfg *config.Config, project *config.Project) error {
-	for _, remote := range project.RcloneRemotes {
-		client := &rclone.Client{RemoteName: remote.Name}
-		if project.Mode == "sync" {
-			if err := client.Sync(project.SourcePaths, remote.Bucket); err != nil {
+// Runner 持有执行备份所需的依赖，CronServer 与 CLI 共用
+type Runner struct {
+	NewTransport TransportFactory
+	Logger       *zap.Logger
+	// Progress 非空时接收每个 remote 的实时传输统计
+	Progress chan<- Progress
+	// Quota 非空时限制每天上传的总字节数
+	Quota *Quota
+	// SkipSafeguard 为 true 时跳过 sync 前的删除/修改阈值检查
+	SkipSafeguard bool
+	// Slots 非空时限制所有项目同时执行的传输数，即同时运行的 rclone 进程数
+	Slots *Semaphore
+}
+
+// Progress 是带有项目与 remote 信息的传输统计
+type Progress struct {
+	Project string
+	Remote  string
+	rclone.Stats
+}
+
+// RunResult 汇总一次项目备份的结果
+type RunResult struct {
+	Stats rclone.Stats
+	// Archived 是 sync-versioned 模式下移动到版本目录的文件数
+	Archived int64
+	// Verify 是启用 verify 时传输后的校验结果
+	Verify VerifyResult
+	// Units 按执行顺序记录每个 (源, remote) 的结果
+	Units []UnitResult
+}
+
+// Failed 返回失败或超时的单元数
+func (r *RunResult) Failed() int {
+	n := 0
+	for _, u := range r.Units {
+		if u.Status == UnitFailed || u.Status == UnitTimedOut {
+			n++
+		}
+	}
+	return n
+}
+
+// UnitResult 的状态
+const (
+	UnitOK      = "ok"
+	UnitFailed  = "failed"
+	UnitSkipped = "skipped" // 备份被取消或已超时，未执行
+	// 执行中因 project.Timeout 或备份窗口结束被取消
+	UnitTimedOut = "timeout"
+	// 执行中因服务停止或 Ctrl-C 被取消
+	UnitCancelled = "cancelled"
+)
+
+// UnitResult 是一个源备份到一个 remote 的结果，各单元互相独立，一个失败不影响其余单元
+type UnitResult struct {
+	Remote   string
+	Source   string
+	Dest     string
+	Status   string
+	Duration time.Duration
+	Stats    rclone.Stats // 包含重试在内所有尝试的统计
+	Archived int64
+	Verify   VerifyResult
+	Err      error
+}
+
+func NewRunner(newTransport TransportFactory, logger *zap.Logger) *Runner {
+	if newTransport == nil {
+		newTransport = DefaultTransport
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Runner{NewTransport: newTransport, Logger: logger}
+}
+
+// RunProject 把项目的每个源备份到每个 remote，最多 project.Concurrency 个 remote 并行执行，
+// 同一 remote 的源依次执行。单元失败时继续执行其余单元，返回的错误由 multierr
+// 合并了所有失败单元的错误，逐个单元的结果按配置顺序见 RunResult.Units。
+// 运行超过 project.Timeout 时取消（终止 rclone），正在执行的单元记为超时。
+func (r *Runner) RunProject(ctx context.Context, cfg *config.Config, project *config.Project) (*RunResult, error) {
+	start := time.Now()
+	if project.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, project.Timeout)
+		defer cancel()
+	}
+	logger := r.Logger.With(zap.String("project", project.Name))
+	units := make([][]UnitResult, len(project.RcloneRemotes))
+	parallel := make(chan struct{}, max(project.Concurrency, 1))
+	var wg sync.WaitGroup
+	for i, remote := range project.RcloneRemotes {
+		wg.Add(1)
+		parallel <- struct{}{}
+		go func() {
+			defer func() {
+				<-parallel
+				wg.Done()
+			}()
+			units[i] = r.runRemote(ctx, cfg, project, remote, start, logger)
+		}()
+	}
+	wg.Wait()
+
+	result := &RunResult{}
+	var errs error
+	for _, remoteUnits := range units {
+		for _, unit := range remoteUnits {
+			result.Stats.Add(unit.Stats)
+			result.Archived += unit.Archived
+			result.Verify.Add(unit.Verify)
+			result.Units = append(result.Units, unit)
+			errs = multierr.Append(errs, unit.Err)
+		}
+	}
+	if ctx.Err() != nil && !errors.Is(errs, ctx.Err()) {
+		errs = multierr.Append(errs, ctx.Err())
+	}
+	return result, errs
+}
+
+// runRemote 依次把项目的每个源备份到 remote，每次尝试前从 r.Slots 获取传输名额
+func (r *Runner) runRemote(ctx context.Context, cfg *config.Config, project *config.Project, remote config.RcloneRemote, start time.Time, logger *zap.Logger) []UnitResult {
+	client, err := r.NewTransport(cfg, remote, logger)
+	units := make([]UnitResult, 0, len(project.SourcePaths))
+	ok := false
+	for _, src := range project.SourcePaths {
+		unit := UnitResult{Remote: remote.Name, Source: src.Path, Dest: destPath(remote, src)}
+		switch {
+		case ctx.Err() != nil:
+			unit.Status = UnitSkipped
+		case err != nil:
+			unit.Status, unit.Err = UnitFailed, err
+		default:
+			unitStart := time.Now()
+			unitLogger := logger.With(zap.String("remote", remote.Name), zap.String("source", src.Path))
+			unit.Err = r.retry(ctx, project.Retry, unitLogger, func() error {
+				if err := r.Slots.Acquire(ctx); err != nil {
+					return err
+				}
+				defer r.Slots.Release()
+				return r.runSource(ctx, client, project, remote, src, start, logger, &unit)
+			})
+			unit.Duration = time.Since(unitStart)
+			switch {
+			case unit.Err == nil:
+				unit.Status = UnitOK
+				ok = true
+			case errors.Is(unit.Err, context.DeadlineExceeded):
+				unit.Status = UnitTimedOut
+			case errors.Is(unit.Err, context.Canceled):
+				unit.Status = UnitCancelled
+			default:
+				unit.Status = UnitFailed
+			}
+		}
+		units = append(units, unit)
+	}
+	if ok && project.Mode == "sync-versioned" && project.Versions.KeepDays > 0 {
+		if _, err := pruneVersions(ctx, client, versionsRoot(project, remote), project.Versions.KeepDays, start, logger); err != nil {
+			logger.Warn("failed to prune old versions", zap.String("remote", remote.Name), zap.Error(err))
+		}
+	}
+	return units
+}
+
+// runSource 把一个源传输到一个 remote，并在配置了 verify 时校验结果，统计累加到 unit
+func (r *Runner) runSource(ctx context.Context, client rclone.Transport, project *config.Project, remote config.RcloneRemote, src config.SourcePath, start time.Time, logger *zap.Logger, unit *UnitResult) error {
+	dst := unit.Dest
+	opts, done := r.transferOptions(project.Name, remote)
+	if project.Mode == "sync-versioned" {
+		opts.BackupDir = path.Join(versionsRoot(project, remote), start.UTC().Format(versionLayout), src.Destination())
+	}
+	sync := project.Mode == "sync" || project.Mode == "sync-versioned"
+	if sync {
+		if err := r.checkSafeguard(ctx, client, project, remote, src, dst, opts, logger); err != nil {
+			done()
+			return err
+		}
+	}
+	err := r.runTransfer(ctx, client, sync, src, dst, opts, logger, unit)
+	done()
+	if opts.BackupDir != "" {
+		// 重试时各次尝试使用同一个版本目录，目录中的文件数已包含之前的尝试
+		unit.Archived = countArchived(ctx, client, opts.BackupDir)
+	}
+	if err != nil {
+		return err
+	}
+	if project.Verify != "" {
+		v, err := verify(ctx, client, project, remote, src, dst, project.Verify)
+		unit.Verify = v
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quotaChunk 是配置了每日配额时一次传输每块预留的字节数。按块预留使同时进行的传输
+// （包括其他项目与进程）都能取得配额，而不是由第一个传输占用全部剩余配额
+const quotaChunk = 256 << 20
+
+// runTransfer 执行 sync 或 copy，统计累加到 unit。配置了每日配额时每块预留用完后
+// 以下一块继续传输（已传输的文件会被跳过），直到完成或当天配额用尽
+func (r *Runner) runTransfer(ctx context.Context, client rclone.Transport, sync bool, src config.SourcePath, dst string, opts rclone.TransferOptions, logger *zap.Logger, unit *UnitResult) error {
+	limit := int64(quotaChunk)
+	for {
+		var reservation *QuotaReservation
+		if r.Quota != nil {
+			var err error
+			if reservation, err = r.Quota.Reserve(ctx, limit); err != nil {
 				return err
 			}
+			opts.MaxTransfer = reservation.Bytes
+		}
+		var (
+			stats rclone.Stats
+			err   error
+		)
+		if sync {
+			stats, err = client.Sync(ctx, src.Path, dst, opts)
 		} else {
-			if err := client.Copy(project.SourcePaths, remote.Bucket); err != nil {
-				return err
+			stats, err = client.Copy(ctx, src.Path, dst, opts)
+		}
+		unit.Stats.Add(stats)
+		r.releaseQuota(reservation, stats.Bytes, logger)
+		if reservation == nil || !errors.Is(err, rclone.ErrMaxTransfer) {
+			return err
+		}
+		// 没有进展说明下一个文件大于预留的块，改为预留全部剩余配额；仍没有进展时配额不足
+		if stats.Bytes == 0 {
+			if limit <= 0 {
+				return fmt.Errorf("%w: %v", ErrQuotaExhausted, err)
 			}
+			limit = 0
 		}
 	}
-	return nil
+}
+
+// releaseQuota 归还预留的配额并记录实际上传的字节数，reservation 为 nil 时不做任何事
+func (r *Runner) releaseQuota(reservation *QuotaReservation, used int64, logger *zap.Logger) {
+	if reservation == nil {
+		return
+	}
+	if err := r.Quota.Release(reservation, used); err != nil {
+		logger.Warn("failed to record transfer quota", zap.Error(err))
+	}
+}
+
+// destPath 返回源在 remote 中的目标路径，每个源写入 bucket 下独立的子路径，避免 sync 时互相删除
+func destPath(remote config.RcloneRemote, src config.SourcePath) string {
+	return path.Join(remote.Bucket, src.Destination())
+}
+
+// transferOptions 为一次传输创建选项，并把进度转发到 r.Progress；传输结束后需调用 done
+func (r *Runner) transferOptions(project string, remote config.RcloneRemote) (rclone.TransferOptions, func()) {
+	o := remote.Options
+	opts := rclone.TransferOptions{
+		Include:    o.Include,
+		Exclude:    o.Exclude,
+		Checksum:   o.UseChecksum(),
+		BwLimit:    o.BwLimit,
+		BwSchedule: o.BwSchedule,
+		TPSLimit:   o.TPSLimit,
+		Transfers:  o.Transfers,
+		Checkers:   o.Checkers,
+		ExtraFlags: o.ExtraFlags,
+	}
+	if r.Progress == nil {
+		return opts, func() {}
+	}
+	ch := make(chan rclone.Stats, 8)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for s := range ch {
+			r.Progress <- Progress{Project: project, Remote: remote.Name, Stats: s}
+		}
+	}()
+	opts.Progress = ch
+	return opts, func() {
+		close(ch)
+		<-finished
+	}
 }
@@ -0,0 +1,217 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/wcx0206/hermes/internal/config"
+	"github.com/wcx0206/hermes/internal/rclone"
+)
+
+// loadProject 加载只有一个项目的配置，project 为该项目的 YAML（不含 name），缩进四个空格
+func loadProject(t *testing.T, project string) (*config.Config, *config.Project) {
+	t.Helper()
+	data := fmt.Sprintf("defaults: {cron: \"0 1 * * *\"}\nprojects:\n  - name: %s\n%s", t.Name(), project)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg, &cfg.Projects[0]
+}
+
+// localProject 加载一个以 mode 把 sources 备份到 local remote 的项目，extra 追加到项目配置中
+func localProject(t *testing.T, mode, bucket string, sources []string, extra string) (*config.Config, *config.Project) {
+	t.Helper()
+	return loadProject(t, fmt.Sprintf(`    mode: %s
+    source_paths: [%s]
+    rclone_remotes: [{name: disk, type: local, bucket: %s}]
+%s`, mode, strings.Join(sources, ", "), bucket, extra))
+}
+
+func writeFiles(t *testing.T, dir string, files ...string) {
+	t.Helper()
+	for _, f := range files {
+		p := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(f), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func assertFiles(t *testing.T, dir string, want ...string) {
+	t.Helper()
+	got := map[string]bool{}
+	err := filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		got[filepath.ToSlash(rel)] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range want {
+		if !got[f] {
+			t.Errorf("%s: missing %s", dir, f)
+		}
+		delete(got, f)
+	}
+	for f := range got {
+		t.Errorf("%s: unexpected %s", dir, f)
+	}
+}
+
+func TestRunProjectTransportFactory(t *testing.T) {
+	src, root := t.TempDir(), t.TempDir()
+	writeFiles(t, src, "a.txt")
+	cfg, project := loadProject(t, fmt.Sprintf(`    mode: copy
+    source_paths: [%s]
+    rclone_remotes: [{name: cloud, bucket: bk}]
+`, src))
+	// 注入的后端代替 rclone，rclone 类型的 remote 也由本地目录模拟
+	var remotes []string
+	factory := func(_ *config.Config, remote config.RcloneRemote, _ *zap.Logger) (rclone.Transport, error) {
+		remotes = append(remotes, remote.Name)
+		return rclone.NewLocalTransport(root), nil
+	}
+	result, err := NewRunner(factory, nil).RunProject(context.Background(), cfg, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remotes) != 1 || remotes[0] != "cloud" {
+		t.Errorf("transports created for %q, want [cloud]", remotes)
+	}
+	if result.Stats.Transfers != 1 {
+		t.Errorf("transfers = %d, want 1", result.Stats.Transfers)
+	}
+	assertFiles(t, root, "bk/"+filepath.Base(src)+"/a.txt")
+}
+
+func TestRunProjectProgress(t *testing.T) {
+	src, bucket := t.TempDir(), t.TempDir()
+	writeFiles(t, src, "a", "b")
+	cfg, project := localProject(t, "copy", bucket, []string{src}, "")
+	progress := make(chan Progress, 16)
+	runner := NewRunner(nil, nil)
+	runner.Progress = progress
+	result, err := runner.RunProject(context.Background(), cfg, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(progress)
+	var last Progress
+	for p := range progress {
+		last = p
+	}
+	if last.Project != project.Name || last.Remote != "disk" || last.Transfers != 2 {
+		t.Errorf("last progress = %+v", last)
+	}
+	if result.Stats.Bytes != last.Bytes {
+		t.Errorf("result bytes = %d, last progress = %d", result.Stats.Bytes, last.Bytes)
+	}
+}
+
+// blockingTransport 的 Copy 在 started 收到通知后阻塞，直到 ctx 结束
+type blockingTransport struct {
+	*rclone.Local
+	started chan<- struct{}
+}
+
+func (b blockingTransport) Copy(ctx context.Context, _, _ string, _ rclone.TransferOptions) (rclone.Stats, error) {
+	b.started <- struct{}{}
+	<-ctx.Done()
+	return rclone.Stats{}, ctx.Err()
+}
+
+func TestRunProjectCancelled(t *testing.T) {
+	root := t.TempDir()
+	a, b := filepath.Join(root, "a"), filepath.Join(root, "b")
+	writeFiles(t, a, "x")
+	writeFiles(t, b, "y")
+	cfg, project := localProject(t, "copy", t.TempDir(), []string{a, b}, "")
+	started := make(chan struct{}, 1)
+	factory := func(*config.Config, config.RcloneRemote, *zap.Logger) (rclone.Transport, error) {
+		return blockingTransport{Local: rclone.NewLocalTransport(""), started: started}, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	result, err := NewRunner(factory, nil).RunProject(ctx, cfg, project)
+	if RunStatus(err) != RunCancelled {
+		t.Fatalf("status = %s (%v), want %s", RunStatus(err), err, RunCancelled)
+	}
+	// 正在执行的单元记为取消而不是失败，尚未开始的单元被跳过
+	if got := []string{result.Units[0].Status, result.Units[1].Status}; got[0] != UnitCancelled || got[1] != UnitSkipped {
+		t.Errorf("unit statuses = %q, want [cancelled skipped]", got)
+	}
+	if result.Failed() != 0 {
+		t.Errorf("Failed() = %d, want 0", result.Failed())
+	}
+}
+
+func TestRunProjectSync(t *testing.T) {
+	root, bucket := t.TempDir(), t.TempDir()
+	data, conf := filepath.Join(root, "data"), filepath.Join(root, "conf")
+	writeFiles(t, data, "a.txt", "sub/b.txt")
+	writeFiles(t, conf, "app.yaml")
+	cfg, project := localProject(t, "sync", bucket, []string{data, conf}, "")
+	runner := NewRunner(nil, nil)
+
+	result, err := runner.RunProject(context.Background(), cfg, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Units) != 2 || result.Failed() != 0 {
+		t.Fatalf("units = %+v, want 2 successful units", result.Units)
+	}
+	if result.Units[0].Dest != filepath.Join(bucket, "data") || result.Units[1].Dest != filepath.Join(bucket, "conf") {
+		t.Errorf("destinations = %s, %s", result.Units[0].Dest, result.Units[1].Dest)
+	}
+	// 每个源写入 bucket 下以 basename 命名的子路径
+	assertFiles(t, bucket, "data/a.txt", "data/sub/b.txt", "conf/app.yaml")
+
+	// 第二次运行只传输变化，并只删除同一源中已不存在的文件
+	if err := os.Remove(filepath.Join(data, "sub/b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, data, "c.txt")
+	result, err = runner.RunProject(context.Background(), cfg, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Stats.Transfers != 1 || result.Stats.Deletes != 1 {
+		t.Errorf("transfers = %d, deletes = %d, want 1 and 1", result.Stats.Transfers, result.Stats.Deletes)
+	}
+	assertFiles(t, bucket, "data/a.txt", "data/c.txt", "conf/app.yaml")
+}
+
+func TestRunProjectBucketRoot(t *testing.T) {
+	src, bucket := t.TempDir(), t.TempDir()
+	writeFiles(t, src, "a.txt")
+	cfg, project := loadProject(t, fmt.Sprintf(`    mode: sync
+    source_paths: [{path: %s, dest: .}]
+    rclone_remotes: [{name: disk, type: local, bucket: %s}]
+`, src, bucket))
+	if _, err := NewRunner(nil, nil).RunProject(context.Background(), cfg, project); err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, bucket, "a.txt")
+}
@@ -0,0 +1,173 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/wcx0206/hermes/internal/rclone"
+)
+
+// ErrQuotaExhausted 表示当天的上传配额已用尽
+var ErrQuotaExhausted = errors.New("daily transfer quota exhausted, uploads resume tomorrow")
+
+// Quota 记录当天已上传的字节数，状态保存在 pid 文件旁，由守护进程与 CLI 共享
+type Quota struct {
+	Limit int64
+	path  string
+}
+
+type quotaState struct {
+	Day   string `json:"day"`
+	Bytes int64  `json:"bytes"`
+	// 正在进行的传输预留的配额，按预留 ID 索引
+	Reserved map[string]quotaReservation `json:"reserved,omitempty"`
+}
+
+type quotaReservation struct {
+	PID   int   `json:"pid"`
+	Bytes int64 `json:"bytes"`
+}
+
+// QuotaReservation 是 Reserve 为一次传输预留的配额
+type QuotaReservation struct {
+	ID    string
+	Bytes int64
+}
+
+var reservationSeq atomic.Int64
+
+// 剩余配额全部被其他传输预留时，等待其归还的检查间隔
+const quotaPollInterval = time.Second
+
+// NewQuota 根据 defaults.daily_quota 创建配额，未配置时返回 nil
+func NewQuota(limit string) (*Quota, error) {
+	if limit == "" {
+		return nil, nil
+	}
+	n, err := rclone.ParseSize(limit)
+	if err != nil {
+		return nil, err
+	}
+	return &Quota{Limit: n, path: stateFilePath("hermes-quota.json")}, nil
+}
+
+// Remaining 返回当天剩余可上传的字节数
+func (q *Quota) Remaining() (int64, error) {
+	st, err := q.update(nil)
+	if err != nil {
+		return 0, err
+	}
+	return max(q.Limit-st.Bytes, 0), nil
+}
+
+// Reserve 为一次传输预留最多 limit 个字节（limit <= 0 时不限），传输以预留的字节数作为
+// --max-transfer，用完后再预留下一块。预留只占用尚未上传也未被其他传输预留的配额，
+// 全部被预留时等待其他传输归还，当天配额已用尽时返回 ErrQuotaExhausted。
+// 传输结束后必须调用 Release。
+func (q *Quota) Reserve(ctx context.Context, limit int64) (*QuotaReservation, error) {
+	res := &QuotaReservation{ID: fmt.Sprintf("%d-%d", os.Getpid(), reservationSeq.Add(1))}
+	for {
+		exhausted := false
+		_, err := q.update(func(st *quotaState) {
+			available := q.Limit - st.Bytes
+			if available <= 0 {
+				exhausted = true
+				return
+			}
+			for _, r := range st.Reserved {
+				available -= r.Bytes
+			}
+			if available <= 0 {
+				return
+			}
+			res.Bytes = available
+			if limit > 0 {
+				res.Bytes = min(available, limit)
+			}
+			if st.Reserved == nil {
+				st.Reserved = make(map[string]quotaReservation)
+			}
+			st.Reserved[res.ID] = quotaReservation{PID: os.Getpid(), Bytes: res.Bytes}
+		})
+		switch {
+		case err != nil:
+			return nil, err
+		case exhausted:
+			return nil, ErrQuotaExhausted
+		case res.Bytes > 0:
+			return res, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(quotaPollInterval):
+		}
+	}
+}
+
+// Release 归还预留的配额，并记录实际上传的 used 个字节
+func (q *Quota) Release(res *QuotaReservation, used int64) error {
+	_, err := q.update(func(st *quotaState) {
+		delete(st.Reserved, res.ID)
+		st.Bytes += max(used, 0)
+	})
+	return err
+}
+
+// update 在持有文件锁时读取状态，fn 非空时修改并写回。守护进程与 CLI 可能同时上传，
+// 读取与写回之间不能被其他进程插入
+func (q *Quota) update(fn func(*quotaState)) (quotaState, error) {
+	today := time.Now().Format(time.DateOnly)
+	f, err := os.OpenFile(q.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return quotaState{}, fmt.Errorf("open quota state: %w", err)
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return quotaState{}, fmt.Errorf("lock quota state: %w", err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return quotaState{}, fmt.Errorf("read quota state: %w", err)
+	}
+	var st quotaState
+	if err := json.Unmarshal(data, &st); err != nil || st.Day != today {
+		st = quotaState{Day: today}
+	}
+	// 进程异常退出时未归还的预留
+	for id, r := range st.Reserved {
+		if !processAlive(r.PID) {
+			delete(st.Reserved, id)
+		}
+	}
+	if fn == nil {
+		return st, nil
+	}
+	fn(&st)
+	data, err = json.Marshal(st)
+	if err != nil {
+		return st, err
+	}
+	if err := f.Truncate(0); err != nil {
+		return st, fmt.Errorf("write quota state: %w", err)
+	}
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return st, fmt.Errorf("write quota state: %w", err)
+	}
+	return st, nil
+}
+
+func processAlive(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return proc.Signal(syscall.Signal(0)) == nil
+}
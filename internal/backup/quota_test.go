@@ -0,0 +1,160 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestQuota(t *testing.T, limit int64) *Quota {
+	return &Quota{Limit: limit, path: filepath.Join(t.TempDir(), "hermes-quota.json")}
+}
+
+func reserve(t *testing.T, q *Quota, limit int64) *QuotaReservation {
+	t.Helper()
+	res, err := q.Reserve(context.Background(), limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestQuotaOverlappingReservations(t *testing.T) {
+	q := newTestQuota(t, 10)
+	a := reserve(t, q, 4)
+	b := reserve(t, q, 4)
+	if a.Bytes != 4 || b.Bytes != 4 {
+		t.Fatalf("reservations = %d and %d, want 4 each", a.Bytes, b.Bytes)
+	}
+	// 只剩未被预留的 2 个字节
+	c := reserve(t, q, 0)
+	if c.Bytes != 2 {
+		t.Fatalf("third reservation = %d, want 2", c.Bytes)
+	}
+
+	// 全部被预留时等待，而不是报告配额用尽
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := q.Reserve(ctx, 4); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want to wait for a release", err)
+	}
+	got := make(chan *QuotaReservation, 1)
+	go func() {
+		res, err := q.Reserve(context.Background(), 4)
+		if err != nil {
+			t.Error(err)
+		}
+		got <- res
+	}()
+	// a 只用了 3 个字节，归还的 1 个字节可以再被预留
+	if err := q.Release(a, 3); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case d := <-got:
+		if d.Bytes != 1 {
+			t.Errorf("reservation after release = %d, want 1", d.Bytes)
+		}
+		q.Release(d, 1)
+	case <-time.After(3 * time.Second):
+		t.Fatal("waiting reservation was not served after release")
+	}
+
+	q.Release(b, 4)
+	q.Release(c, 2)
+	if remaining, _ := q.Remaining(); remaining != 0 {
+		t.Errorf("remaining = %d, want 0", remaining)
+	}
+	if _, err := q.Reserve(context.Background(), 4); !errors.Is(err, ErrQuotaExhausted) {
+		t.Errorf("err = %v, want ErrQuotaExhausted", err)
+	}
+}
+
+func TestQuotaDropsStaleState(t *testing.T) {
+	q := newTestQuota(t, 10)
+	// 已退出进程的预留与前一天的用量都不再占用配额
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skip(err)
+	}
+	st := quotaState{
+		Day:      time.Now().Format(time.DateOnly),
+		Bytes:    4,
+		Reserved: map[string]quotaReservation{"dead": {PID: cmd.Process.Pid, Bytes: 6}},
+	}
+	data, _ := json.Marshal(st)
+	if err := os.WriteFile(q.path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if res := reserve(t, q, 0); res.Bytes != 6 {
+		t.Errorf("reservation = %d, want the 6 bytes held by the dead process", res.Bytes)
+	}
+
+	st = quotaState{Day: "2000-01-01", Bytes: 10}
+	data, _ = json.Marshal(st)
+	if err := os.WriteFile(q.path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if remaining, err := q.Remaining(); err != nil || remaining != 10 {
+		t.Errorf("remaining = %d, %v, want a fresh day", remaining, err)
+	}
+}
+
+func TestRunProjectQuota(t *testing.T) {
+	root, bucket := t.TempDir(), t.TempDir()
+	data := filepath.Join(root, "data")
+	writeFiles(t, data, "aaaa", "bbbb", "cccc")
+	cfg, project := localProject(t, "copy", bucket, []string{data}, "")
+	runner := NewRunner(nil, nil)
+	runner.Quota = newTestQuota(t, 10)
+
+	result, err := runner.RunProject(context.Background(), cfg, project)
+	if RunStatus(err) != RunQuota {
+		t.Fatalf("status = %s (%v), want %s", RunStatus(err), err, RunQuota)
+	}
+	// 只记录实际上传的字节，预留全部归还
+	if result.Stats.Bytes != 8 {
+		t.Errorf("uploaded %d bytes, want 8", result.Stats.Bytes)
+	}
+	st, err := runner.Quota.update(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Bytes != 8 || len(st.Reserved) != 0 {
+		t.Errorf("quota state = %+v, want 8 bytes and no reservations", st)
+	}
+	assertFiles(t, bucket, "data/aaaa", "data/bbbb")
+}
+
+func TestRunProjectQuotaParallelRemotes(t *testing.T) {
+	root := t.TempDir()
+	data := filepath.Join(root, "data")
+	writeFiles(t, data, "aaaa", "bbbb", "cccc")
+	cfg, project := loadProject(t, fmt.Sprintf(`    mode: copy
+    concurrency: 2
+    source_paths: [%s]
+    rclone_remotes:
+      - {name: one, type: local, bucket: %s}
+      - {name: two, type: local, bucket: %s}
+`, data, filepath.Join(root, "one"), filepath.Join(root, "two")))
+	runner := NewRunner(nil, nil)
+	runner.Quota = newTestQuota(t, 10)
+
+	result, err := runner.RunProject(context.Background(), cfg, project)
+	if !errors.Is(err, ErrQuotaExhausted) {
+		t.Fatalf("err = %v, want ErrQuotaExhausted", err)
+	}
+	// 并行的 remote 合计不超过当天配额
+	if result.Stats.Bytes > 10 || result.Stats.Bytes < 8 {
+		t.Errorf("uploaded %d bytes, want 8-10 within the quota of 10", result.Stats.Bytes)
+	}
+	if remaining, _ := runner.Quota.Remaining(); remaining != 10-result.Stats.Bytes {
+		t.Errorf("remaining = %d after uploading %d bytes", remaining, result.Stats.Bytes)
+	}
+}
@@ -0,0 +1,158 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+
+	"github.com/wcx0206/hermes/internal/config"
+	"github.com/wcx0206/hermes/internal/rclone"
+)
+
+// RestoreOptions 是一次恢复的参数
+type RestoreOptions struct {
+	// Remote 是恢复来源的 remote 名称，为空时使用项目的第一个 remote
+	Remote string
+	// To 非空时恢复到该目录下的 <dest> 子目录，而不是源的原始路径
+	To string
+	// Include 非空时只恢复匹配的文件，规则与 options.include 相同
+	Include []string
+	// Force 为 true 时允许写入非空的目标
+	Force  bool
+	DryRun bool
+}
+
+// RestoreTarget 记录一个源的恢复位置
+type RestoreTarget struct {
+	Source string // 后端中的路径
+	Target string // 本地目录
+}
+
+// RestoreResult 汇总一次项目恢复的结果
+type RestoreResult struct {
+	Remote  string
+	Targets []RestoreTarget
+	Stats   rclone.Stats
+}
+
+// ErrTargetNotEmpty 表示恢复目标已有数据且未指定 Force
+var ErrTargetNotEmpty = errors.New("restore target is not empty")
+
+// RestoreProject 把项目的备份从 remote 复制回本地。所有目标在传输前统一检查，
+// 任一目标非空且未指定 Force 时不会写入任何文件。
+func (r *Runner) RestoreProject(ctx context.Context, cfg *config.Config, project *config.Project, opts RestoreOptions) (*RestoreResult, error) {
+	remote, err := restoreRemote(project, opts.Remote)
+	if err != nil {
+		return nil, err
+	}
+	logger := r.Logger.With(zap.String("project", project.Name))
+	client, err := r.NewTransport(cfg, remote, logger)
+	if err != nil {
+		return nil, err
+	}
+	result := &RestoreResult{Remote: remote.Name}
+	for _, src := range project.SourcePaths {
+		dst := destPath(remote, src)
+		isFile, err := sourceIsFile(ctx, client, src, dst)
+		if err != nil {
+			return nil, err
+		}
+		target, existing := restoreTarget(src, opts.To, isFile)
+		if !opts.Force && !opts.DryRun {
+			if err := checkRestoreTarget(existing); err != nil {
+				return nil, err
+			}
+		}
+		result.Targets = append(result.Targets, RestoreTarget{Source: dst, Target: target})
+	}
+	for _, t := range result.Targets {
+		topts, done := r.transferOptions(project.Name, remote)
+		// 备份时的过滤规则已经作用于后端中的数据，恢复时只使用指定的文件选择
+		topts.Include, topts.Exclude = opts.Include, nil
+		topts.DryRun = opts.DryRun
+		stats, err := client.Restore(ctx, t.Source, t.Target, topts)
+		done()
+		result.Stats.Add(stats)
+		if err != nil {
+			return result, err
+		}
+		logger.Info("restore done",
+			zap.String("remote", remote.Name),
+			zap.String("source", t.Source),
+			zap.String("target", t.Target),
+			zap.Bool("dry_run", opts.DryRun),
+			zap.Int64("transfers", stats.Transfers))
+	}
+	return result, nil
+}
+
+// restoreRemote 返回名为 name 的 remote，name 为空时返回项目的第一个 remote
+func restoreRemote(project *config.Project, name string) (config.RcloneRemote, error) {
+	remotes, err := projectRemotes(project, name)
+	if err != nil {
+		return config.RcloneRemote{}, err
+	}
+	return remotes[0], nil
+}
+
+// sourceIsFile 判断 src 是否为单个文件。本地路径已不存在时（例如被误删），
+// 根据备份中是否只有一个与源同名的文件来判断。
+func sourceIsFile(ctx context.Context, client rclone.Transport, src config.SourcePath, dst string) (bool, error) {
+	if info, err := os.Stat(src.Path); err == nil {
+		return !info.IsDir(), nil
+	}
+	entries, err := client.List(ctx, dst, false)
+	if err != nil {
+		return false, err
+	}
+	return len(entries) == 1 && !entries[0].IsDir && entries[0].Path == filepath.Base(src.Path), nil
+}
+
+// restoreTarget 返回 src 恢复到的本地目录，以及用于判断是否会覆盖数据的路径。
+// 源为单个文件时备份位于 <dest>/<文件名>，恢复到原文件所在目录（或 to 目录）即可还原该文件。
+func restoreTarget(src config.SourcePath, to string, isFile bool) (target, existing string) {
+	if isFile {
+		dir := filepath.Dir(src.Path)
+		if to != "" {
+			dir = to
+		}
+		return dir, filepath.Join(dir, filepath.Base(src.Path))
+	}
+	if to != "" {
+		target = filepath.Join(to, filepath.FromSlash(src.Destination()))
+		return target, target
+	}
+	return src.Path, src.Path
+}
+
+// checkRestoreTarget 在 p 为非空目录或已存在的文件时返回 ErrTargetNotEmpty
+func checkRestoreTarget(p string) error {
+	info, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %s exists", ErrTargetNotEmpty, p)
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Readdirnames(1); err != io.EOF {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %s", ErrTargetNotEmpty, p)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRestoreProject(t *testing.T) {
+	root, bucket := t.TempDir(), t.TempDir()
+	data, conf := filepath.Join(root, "data"), filepath.Join(root, "etc", "app.conf")
+	writeFiles(t, data, "a", "sub/b")
+	writeFiles(t, filepath.Dir(conf), "app.conf")
+	cfg, project := localProject(t, "copy", bucket, []string{data, conf}, "")
+	runner := NewRunner(nil, nil)
+	if _, err := runner.RunProject(context.Background(), cfg, project); err != nil {
+		t.Fatal(err)
+	}
+
+	// 原始路径仍有数据时拒绝恢复，且不写入任何文件
+	if err := os.WriteFile(filepath.Join(data, "a"), []byte("local edit"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.RestoreProject(context.Background(), cfg, project, RestoreOptions{}); !errors.Is(err, ErrTargetNotEmpty) {
+		t.Fatalf("err = %v, want ErrTargetNotEmpty", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(data, "a")); string(got) != "local edit" {
+		t.Errorf("refused restore overwrote a: %q", got)
+	}
+
+	// dry-run 不检查目标也不写入
+	res, err := runner.RestoreProject(context.Background(), cfg, project, RestoreOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Stats.Transfers != 1 {
+		t.Errorf("dry-run transfers = %d, want 1", res.Stats.Transfers)
+	}
+	if got, _ := os.ReadFile(filepath.Join(data, "a")); string(got) != "local edit" {
+		t.Errorf("dry run overwrote a: %q", got)
+	}
+
+	if _, err := runner.RestoreProject(context.Background(), cfg, project, RestoreOptions{Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(data, "a")); string(got) != "a" {
+		t.Errorf("forced restore left a = %q", got)
+	}
+}
+
+func TestRestoreProjectTo(t *testing.T) {
+	root, bucket, to := t.TempDir(), t.TempDir(), t.TempDir()
+	data, conf := filepath.Join(root, "data"), filepath.Join(root, "etc", "app.conf")
+	writeFiles(t, data, "a", "sub/b", "c")
+	writeFiles(t, filepath.Dir(conf), "app.conf")
+	cfg, project := localProject(t, "copy", bucket, []string{data, conf}, "")
+	runner := NewRunner(nil, nil)
+	if _, err := runner.RunProject(context.Background(), cfg, project); err != nil {
+		t.Fatal(err)
+	}
+	// 源已被删除时仍能根据备份判断单个文件的源
+	if err := os.RemoveAll(root); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := runner.RestoreProject(context.Background(), cfg, project, RestoreOptions{To: to, Include: []string{"sub/**", "app.conf"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Remote != "disk" || len(res.Targets) != 2 {
+		t.Errorf("result = %+v", res)
+	}
+	assertFiles(t, to, "data/sub/b", "app.conf")
+
+	// 目标目录已有文件时同样拒绝
+	if _, err := runner.RestoreProject(context.Background(), cfg, project, RestoreOptions{To: to}); !errors.Is(err, ErrTargetNotEmpty) {
+		t.Fatalf("err = %v, want ErrTargetNotEmpty", err)
+	}
+	if _, err := runner.RestoreProject(context.Background(), cfg, project, RestoreOptions{Remote: "missing"}); err == nil {
+		t.Error("restore from an unknown remote succeeded")
+	}
+}
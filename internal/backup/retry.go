@@ -0,0 +1,49 @@
+package backup
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wcx0206/hermes/internal/config"
+	"github.com/wcx0206/hermes/internal/rclone"
+)
+
+// retry 执行 fn，失败且错误分类可重试时按 policy 退避后再次执行，直到成功、
+// 达到最多尝试次数或 ctx 结束。返回最后一次的错误。
+func (r *Runner) retry(ctx context.Context, policy config.Retry, logger *zap.Logger, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || ctx.Err() != nil || attempt >= policy.MaxAttempts {
+			return err
+		}
+		category := rclone.Classify(err)
+		if !policy.Retryable(category) {
+			return err
+		}
+		delay := jitter(policy.Backoff(attempt), policy.Jitter)
+		logger.Warn("backup attempt failed, retrying",
+			zap.Int("attempt", attempt),
+			zap.Int("max_attempts", policy.MaxAttempts),
+			zap.String("category", string(category)),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// jitter 在 d 的基础上随机浮动 ±fraction
+func jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || d <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + fraction*(2*rand.Float64()-1)))
+}
@@ -0,0 +1,115 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+
+	"github.com/wcx0206/hermes/internal/config"
+	"github.com/wcx0206/hermes/internal/rclone"
+)
+
+// failingTransport 的 Copy 在前 failures 次调用时返回 err，之后交给本地后端
+type failingTransport struct {
+	*rclone.Local
+	calls    *atomic.Int32
+	failures int32
+	err      error
+}
+
+func (f failingTransport) Copy(ctx context.Context, src, dst string, opts rclone.TransferOptions) (rclone.Stats, error) {
+	if f.calls.Add(1) <= f.failures {
+		return rclone.Stats{}, f.err
+	}
+	return f.Local.Copy(ctx, src, dst, opts)
+}
+
+func TestRunProjectRetryAndContinue(t *testing.T) {
+	src, root := t.TempDir(), t.TempDir()
+	writeFiles(t, src, "a")
+	cfg, project := loadProject(t, fmt.Sprintf(`    mode: copy
+    source_paths: [%s]
+    retry: {max_attempts: 3, initial_backoff: 1ms, max_backoff: 2ms}
+    rclone_remotes:
+      - {name: flaky, bucket: %s}
+      - {name: denied, bucket: %s}
+      - {name: down, bucket: %s}
+      - {name: disk, bucket: %s}
+`, src, filepath.Join(root, "flaky"), filepath.Join(root, "denied"), filepath.Join(root, "down"), filepath.Join(root, "disk")))
+
+	transient := &rclone.Error{Op: "copy", Category: rclone.CategoryTransient, Err: errors.New("connection reset")}
+	auth := &rclone.Error{Op: "copy", Category: rclone.CategoryAuth, Err: errors.New("403 Forbidden")}
+	calls := map[string]*atomic.Int32{"flaky": {}, "denied": {}, "down": {}, "disk": {}}
+	factory := func(_ *config.Config, remote config.RcloneRemote, _ *zap.Logger) (rclone.Transport, error) {
+		tr := failingTransport{Local: rclone.NewLocalTransport(""), calls: calls[remote.Name]}
+		switch remote.Name {
+		case "flaky":
+			tr.failures, tr.err = 2, transient
+		case "denied":
+			tr.failures, tr.err = 10, auth
+		case "down":
+			tr.failures, tr.err = 10, transient
+		}
+		return tr, nil
+	}
+
+	start := time.Now()
+	result, err := NewRunner(factory, nil).RunProject(context.Background(), cfg, project)
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("retries took %s", time.Since(start))
+	}
+	// 临时错误重试到成功或达到 max_attempts，认证错误不重试
+	for name, want := range map[string]int32{"flaky": 3, "denied": 1, "down": 3, "disk": 1} {
+		if got := calls[name].Load(); got != want {
+			t.Errorf("%s: %d attempts, want %d", name, got, want)
+		}
+	}
+	// 失败的 remote 不影响其余 remote
+	statuses := map[string]string{}
+	for _, u := range result.Units {
+		statuses[u.Remote] = u.Status
+	}
+	want := map[string]string{"flaky": UnitOK, "denied": UnitFailed, "down": UnitFailed, "disk": UnitOK}
+	if fmt.Sprint(statuses) != fmt.Sprint(want) {
+		t.Errorf("unit statuses = %v, want %v", statuses, want)
+	}
+	assertFiles(t, root, "flaky/"+filepath.Base(src)+"/a", "disk/"+filepath.Base(src)+"/a")
+	if errs := multierr.Errors(err); len(errs) != 2 || !errors.Is(err, rclone.ErrAuth) || !errors.Is(err, rclone.ErrTransient) {
+		t.Errorf("err = %v, want the auth and transient failures", err)
+	}
+	if result.Failed() != 2 || RunStatus(err) != RunFailed {
+		t.Errorf("failed = %d, status = %s", result.Failed(), RunStatus(err))
+	}
+}
+
+func TestRetryStopsOnCancel(t *testing.T) {
+	r := NewRunner(nil, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	err := r.retry(ctx, config.Retry{MaxAttempts: 5, InitialBackoff: time.Hour, MaxBackoff: time.Hour}, zap.NewNop(), func() error {
+		attempts++
+		cancel()
+		return &rclone.Error{Category: rclone.CategoryTransient, Err: errors.New("timeout")}
+	})
+	if attempts != 1 || err == nil {
+		t.Errorf("attempts = %d, err = %v, want one failed attempt", attempts, err)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	if got := jitter(time.Minute, 0); got != time.Minute {
+		t.Errorf("jitter without fraction = %s", got)
+	}
+	for range 100 {
+		if got := jitter(time.Minute, 0.2); got < 48*time.Second || got > 72*time.Second {
+			t.Fatalf("jitter(1m, 0.2) = %s, want within ±20%%", got)
+		}
+	}
+}
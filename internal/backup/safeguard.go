@@ -0,0 +1,73 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/wcx0206/hermes/internal/config"
+	"github.com/wcx0206/hermes/internal/rclone"
+)
+
+// SafeguardError 表示一次 sync 计划删除或修改的文件超过了项目配置的阈值
+type SafeguardError struct {
+	Source   string
+	Remote   string
+	Deletes  int
+	Modifies int
+	Existing int // 目标端现有文件数
+}
+
+func (e *SafeguardError) Error() string {
+	return fmt.Sprintf("sync of %s to %s aborted by safeguard: %d deletions and %d modifications planned out of %d remote files",
+		e.Source, e.Remote, e.Deletes, e.Modifies, e.Existing)
+}
+
+// checkSafeguard 在 sync 前以 dry-run 执行同一次 sync（相同的比较方式、过滤规则与 extra_flags），
+// 计划的删除与修改超过阈值时返回 *SafeguardError。
+// 空的或未挂载的源目录会表现为删除全部远端文件，从而被拦截。
+func (r *Runner) checkSafeguard(ctx context.Context, client rclone.Transport, project *config.Project, remote config.RcloneRemote, src config.SourcePath, dst string, opts rclone.TransferOptions, logger *zap.Logger) error {
+	g := project.Safeguard
+	if r.SkipSafeguard || !g.Enabled() {
+		return nil
+	}
+	opts.DryRun = true
+	opts.Progress = nil
+	opts.MaxTransfer = 0
+	stats, err := client.Sync(ctx, src.Path, dst, opts)
+	if err != nil {
+		return fmt.Errorf("safeguard dry run of %s failed: %w", src.Path, err)
+	}
+	// dry-run 的传输数同时包含新文件与被覆盖的文件，按名称比较两端得到新文件数与目标端现有文件数
+	listing, err := client.Check(ctx, src.Path, dst, rclone.CheckOptions{
+		Include: opts.Include,
+		Exclude: opts.Exclude,
+		Compare: rclone.CompareSize,
+	})
+	if err != nil {
+		return fmt.Errorf("safeguard check of %s failed: %w", src.Path, err)
+	}
+	deletes := int(stats.Deletes)
+	modifies := max(int(stats.Transfers)-len(listing.Missing), 0)
+	existing := listing.Same + len(listing.Differ) + len(listing.Extra)
+	changes := deletes + modifies
+	percent := 0.0
+	if existing > 0 {
+		percent = float64(changes) * 100 / float64(existing)
+	}
+	if (g.MaxChanges > 0 && changes > g.MaxChanges) || (g.MaxChangePercent > 0 && percent > g.MaxChangePercent) {
+		err := &SafeguardError{Source: src.Path, Remote: remote.Name + ":" + dst, Deletes: deletes, Modifies: modifies, Existing: existing}
+		logger.Error("sync aborted by safeguard",
+			zap.String("severity", "alert"),
+			zap.String("remote", remote.Name),
+			zap.String("source", src.Path),
+			zap.Int("deletes", deletes),
+			zap.Int("modifies", modifies),
+			zap.Int("existing", existing),
+			zap.Float64("percent", percent),
+		)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunProjectSafeguard(t *testing.T) {
+	root, bucket := t.TempDir(), t.TempDir()
+	data := filepath.Join(root, "data")
+	writeFiles(t, data, "1", "2", "3", "4", "5")
+	cfg, project := localProject(t, "sync", bucket, []string{data}, "    safeguard: {max_change_percent: 50}\n")
+	runner := NewRunner(nil, nil)
+	if _, err := runner.RunProject(context.Background(), cfg, project); err != nil {
+		t.Fatal(err)
+	}
+
+	// 新增文件不计入阈值
+	writeFiles(t, data, "6", "7", "8", "9", "10", "11")
+	if _, err := runner.RunProject(context.Background(), cfg, project); err != nil {
+		t.Fatalf("adding files tripped the safeguard: %v", err)
+	}
+
+	// 源目录被清空（例如未挂载）时 sync 会删除全部远端文件，被拦截
+	if err := os.RemoveAll(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	result, err := runner.RunProject(context.Background(), cfg, project)
+	var sg *SafeguardError
+	if !errors.As(err, &sg) {
+		t.Fatalf("err = %v, want *SafeguardError", err)
+	}
+	if sg.Deletes != 11 || sg.Existing != 11 {
+		t.Errorf("safeguard error = %+v, want 11 deletes of 11 files", sg)
+	}
+	if result.Units[0].Status != UnitFailed || result.Stats.Deletes != 0 {
+		t.Errorf("unit = %+v, want a failed unit without deletions", result.Units[0])
+	}
+	if entries, _ := os.ReadDir(filepath.Join(bucket, "data")); len(entries) != 11 {
+		t.Errorf("remote has %d files after the aborted sync, want 11", len(entries))
+	}
+
+	runner.SkipSafeguard = true
+	if _, err := runner.RunProject(context.Background(), cfg, project); err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, bucket)
+}
+
+func TestRunProjectSafeguardModifications(t *testing.T) {
+	root, bucket := t.TempDir(), t.TempDir()
+	data := filepath.Join(root, "data")
+	writeFiles(t, data, "1", "2", "3", "4")
+	cfg, project := localProject(t, "sync", bucket, []string{data}, "    safeguard: {max_changes: 2}\n")
+	runner := NewRunner(nil, nil)
+	if _, err := runner.RunProject(context.Background(), cfg, project); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []string{"1", "2", "3"} {
+		if err := os.WriteFile(filepath.Join(data, f), []byte("changed "+f), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := runner.RunProject(context.Background(), cfg, project)
+	var sg *SafeguardError
+	if !errors.As(err, &sg) || sg.Modifies != 3 || sg.Deletes != 0 {
+		t.Fatalf("err = %v, want a safeguard error with 3 modifications", err)
+	}
+}
+
+func TestRunProjectSafeguardRespectsFilters(t *testing.T) {
+	root, bucket := t.TempDir(), t.TempDir()
+	data := filepath.Join(root, "data")
+	writeFiles(t, data, "a", "b", "c.tmp", "d.tmp")
+	cfg, project := localProject(t, "sync", bucket, []string{data}, "    options: {exclude: [\"*.tmp\"]}\n    safeguard: {max_changes: 1}\n")
+	runner := NewRunner(nil, nil)
+	if _, err := runner.RunProject(context.Background(), cfg, project); err != nil {
+		t.Fatal(err)
+	}
+	// 被排除的文件不会被 sync 删除，也不计入阈值
+	writeFiles(t, filepath.Join(bucket, "data"), "x.tmp", "y.tmp", "z.tmp")
+	if _, err := runner.RunProject(context.Background(), cfg, project); err != nil {
+		t.Fatalf("excluded remote files tripped the safeguard: %v", err)
+	}
+}
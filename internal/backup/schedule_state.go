@@ -0,0 +1,90 @@
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"syscall"
+	"time"
+)
+
+// ProjectSchedule 记录项目最近一次计划运行与成功运行的时间
+type ProjectSchedule struct {
+	LastScheduled time.Time `json:"last_scheduled,omitempty"`
+	LastSuccess   time.Time `json:"last_success,omitempty"`
+}
+
+// ScheduleState 是保存在 pid 文件旁的各项目 ProjectSchedule，守护进程重启后据此
+// 判断停机期间错过的运行。守护进程与 CLI 共享，读写时对文件加 flock
+type ScheduleState struct {
+	path string
+}
+
+func OpenScheduleState() *ScheduleState {
+	return &ScheduleState{path: stateFilePath("hermes-schedule.json")}
+}
+
+// Load 返回所有项目的状态，文件不存在时返回空结果
+func (s *ScheduleState) Load() (map[string]ProjectSchedule, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]ProjectSchedule{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open schedule state: %w", err)
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_SH); err != nil {
+		return nil, fmt.Errorf("lock schedule state: %w", err)
+	}
+	return s.decode(f)
+}
+
+// Update 在持有锁时读取、修改并写回项目的状态
+func (s *ScheduleState) Update(project string, fn func(*ProjectSchedule)) error {
+	f, err := os.OpenFile(s.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("open schedule state: %w", err)
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("lock schedule state: %w", err)
+	}
+	state, err := s.decode(f)
+	if err != nil {
+		return err
+	}
+	ps := state[project]
+	fn(&ps)
+	state[project] = ps
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return fmt.Errorf("write schedule state: %w", err)
+	}
+	if _, err := f.WriteAt(append(data, '\n'), 0); err != nil {
+		return fmt.Errorf("write schedule state: %w", err)
+	}
+	return nil
+}
+
+// decode 解析状态文件，空文件视为没有记录
+func (s *ScheduleState) decode(f *os.File) (map[string]ProjectSchedule, error) {
+	state := make(map[string]ProjectSchedule)
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("read schedule state: %w", err)
+	}
+	if len(data) == 0 {
+		return state, nil
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse schedule state %s: %w", s.path, err)
+	}
+	return state, nil
+}
@@ -0,0 +1,49 @@
+package backup
+
+import "context"
+
+// Semaphore 限制同时执行的任务数，nil 表示不限制
+type Semaphore struct {
+	slots chan struct{}
+}
+
+// NewSemaphore 创建容量为 n 的信号量，n <= 0 时返回 nil
+func NewSemaphore(n int) *Semaphore {
+	if n <= 0 {
+		return nil
+	}
+	return &Semaphore{slots: make(chan struct{}, n)}
+}
+
+// Acquire 阻塞直到获得名额或 ctx 结束
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TryAcquire 在有空闲名额时获得名额并返回 true，不阻塞
+func (s *Semaphore) TryAcquire() bool {
+	if s == nil {
+		return true
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Semaphore) Release() {
+	if s == nil {
+		return
+	}
+	<-s.slots
+}
@@ -0,0 +1,53 @@
+package backup
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// delayedSchedule 把 cron 表达式计算出的每个时刻推迟 offset，再加上 [0, jitter) 的随机延迟
+type delayedSchedule struct {
+	cron.Schedule
+	offset time.Duration
+	jitter time.Duration
+}
+
+// delaySchedule 在配置了 stagger 或 jitter 时包装 sched，返回包装后的 schedule 与固定偏移
+func delaySchedule(sched cron.Schedule, project string, stagger, jitter time.Duration) (cron.Schedule, time.Duration) {
+	if stagger <= 0 && jitter <= 0 {
+		return sched, 0
+	}
+	host, _ := os.Hostname()
+	offset := staggerOffset(host, project, stagger)
+	return &delayedSchedule{Schedule: sched, offset: offset, jitter: jitter}, offset
+}
+
+func (s *delayedSchedule) Next(t time.Time) time.Time {
+	// 以未推迟的时刻计算下一次，避免推迟后的触发时间落入下一个周期而漏掉一次
+	next := s.Schedule.Next(t.Add(-s.offset))
+	if next.IsZero() {
+		return next
+	}
+	next = next.Add(s.offset)
+	if s.jitter > 0 {
+		next = next.Add(rand.N(s.jitter))
+	}
+	return next
+}
+
+// staggerOffset 由主机名与项目名的哈希得到 [0, window) 内的固定偏移，
+// 同一主机上的同一项目每次启动都相同，不同主机或项目相互错开
+func staggerOffset(host, project string, window time.Duration) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(host))
+	h.Write([]byte{0})
+	h.Write([]byte(project))
+	return time.Duration(h.Sum64() % uint64(window))
+}
@@ -0,0 +1,66 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func TestDelayedScheduleNext(t *testing.T) {
+	hourly, err := cron.ParseStandard("0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &delayedSchedule{Schedule: hourly, offset: 10 * time.Minute}
+	at := func(h, m int) time.Time { return time.Date(2024, 3, 1, h, m, 0, 0, time.UTC) }
+	tests := []struct {
+		from, want time.Time
+	}{
+		{at(9, 55), at(10, 10)},
+		// 未推迟的 10:00 已过但推迟后的 10:10 尚未到达，不能漏掉
+		{at(10, 5), at(10, 10)},
+		{at(10, 10), at(11, 10)},
+		{at(23, 30), time.Date(2024, 3, 2, 0, 10, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := s.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("Next(%s) = %s, want %s", tt.from.Format(time.TimeOnly), got, tt.want)
+		}
+	}
+
+	s.jitter = time.Minute
+	for range 100 {
+		got := s.Next(at(9, 55))
+		if got.Before(at(10, 10)) || !got.Before(at(10, 11)) {
+			t.Fatalf("Next with jitter = %s, want within [10:10, 10:11)", got)
+		}
+	}
+}
+
+func TestDelaySchedule(t *testing.T) {
+	hourly, _ := cron.ParseStandard("0 * * * *")
+	if sched, offset := delaySchedule(hourly, "p", 0, 0); sched != hourly || offset != 0 {
+		t.Error("schedule without stagger and jitter was wrapped")
+	}
+	if _, offset := delaySchedule(hourly, "p", 0, time.Minute); offset != 0 {
+		t.Errorf("offset with jitter only = %s, want 0", offset)
+	}
+}
+
+func TestStaggerOffset(t *testing.T) {
+	window := 30 * time.Minute
+	a := staggerOffset("host-a", "vaultwarden", window)
+	if a < 0 || a >= window {
+		t.Fatalf("offset %s outside [0, %s)", a, window)
+	}
+	if b := staggerOffset("host-a", "vaultwarden", window); a != b {
+		t.Errorf("offset is not stable: %s != %s", a, b)
+	}
+	if b := staggerOffset("host-b", "vaultwarden", window); a == b {
+		t.Errorf("hosts share offset %s", a)
+	}
+	if got := staggerOffset("host-a", "vaultwarden", 0); got != 0 {
+		t.Errorf("offset without window = %s, want 0", got)
+	}
+}
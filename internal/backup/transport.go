@@ -0,0 +1,22 @@
+package backup
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/wcx0206/hermes/internal/config"
+	"github.com/wcx0206/hermes/internal/rclone"
+)
+
+// TransportFactory 根据 remote 配置创建传输后端，便于注入 rclone 以外的实现
+type TransportFactory func(cfg *config.Config, remote config.RcloneRemote, logger *zap.Logger) (rclone.Transport, error)
+
+func DefaultTransport(cfg *config.Config, remote config.RcloneRemote, logger *zap.Logger) (rclone.Transport, error) {
+	if remote.Type == "local" {
+		return rclone.NewLocalTransport(""), nil
+	}
+	return rclone.NewRcloneClient(rclone.Options{
+		RemoteName:  remote.Name,
+		GracePeriod: cfg.Defaults.StopGracePeriod,
+		Logger:      logger,
+	}), nil
+}
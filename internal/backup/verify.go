@@ -0,0 +1,105 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/wcx0206/hermes/internal/config"
+	"github.com/wcx0206/hermes/internal/rclone"
+)
+
+// VerifyResult 记录源与目标比较后不一致的文件数
+type VerifyResult struct {
+	Checked int      `json:"checked"`
+	Missing int      `json:"missing"` // 源中存在但目标中缺失
+	Differ  int      `json:"differ"`
+	Extra   int      `json:"extra"` // 仅 sync 模式下计为不一致
+	Failed  []string `json:"failed,omitempty"`
+}
+
+func (v *VerifyResult) Add(o VerifyResult) {
+	v.Checked += o.Checked
+	v.Missing += o.Missing
+	v.Differ += o.Differ
+	v.Extra += o.Extra
+	v.Failed = append(v.Failed, o.Failed...)
+}
+
+func (v *VerifyResult) OK() bool {
+	return v.Missing == 0 && v.Differ == 0 && v.Extra == 0
+}
+
+// VerifyError 表示备份后的校验发现了不一致
+type VerifyError struct {
+	Source string
+	Remote string
+	VerifyResult
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("verification of %s against %s failed: %d missing, %d differing, %d extra files",
+		e.Source, e.Remote, e.Missing, e.Differ, e.Extra)
+}
+
+// 校验结果中最多记录的不一致文件路径数
+const maxVerifyFailures = 20
+
+// verify 按 mode 比较 src 与 dst；copy 模式下目标端多出的文件不算不一致
+func verify(ctx context.Context, client rclone.Transport, project *config.Project, remote config.RcloneRemote, src config.SourcePath, dst, mode string) (VerifyResult, error) {
+	res, err := client.Check(ctx, src.Path, dst, rclone.CheckOptions{
+		Include: remote.Options.Include,
+		Exclude: remote.Options.Exclude,
+		Compare: mode,
+	})
+	if err != nil {
+		return VerifyResult{}, err
+	}
+	v := VerifyResult{
+		Checked: res.Same + len(res.Missing) + len(res.Differ),
+		Missing: len(res.Missing),
+		Differ:  len(res.Differ),
+	}
+	failed := append(append([]string{}, res.Missing...), res.Differ...)
+	if project.Mode != "copy" {
+		v.Extra = len(res.Extra)
+		failed = append(failed, res.Extra...)
+	}
+	if len(failed) > maxVerifyFailures {
+		failed = failed[:maxVerifyFailures]
+	}
+	v.Failed = failed
+	if !v.OK() {
+		return v, &VerifyError{Source: src.Path, Remote: remote.Name + ":" + dst, VerifyResult: v}
+	}
+	return v, nil
+}
+
+// VerifyProject 独立校验项目的所有源与 remote，mode 为空时使用项目的 verify 配置，
+// 项目未配置时按大小与修改时间比较
+func (r *Runner) VerifyProject(ctx context.Context, cfg *config.Config, project *config.Project, mode string) (*VerifyResult, error) {
+	if mode == "" {
+		mode = project.Verify
+	}
+	if mode == "" {
+		mode = rclone.CompareModTime
+	}
+	logger := r.Logger.With(zap.String("project", project.Name))
+	total := &VerifyResult{}
+	var firstErr error
+	for _, remote := range project.RcloneRemotes {
+		client, err := r.NewTransport(cfg, remote, logger)
+		if err != nil {
+			return total, err
+		}
+		for _, src := range project.SourcePaths {
+			v, err := verify(ctx, client, project, remote, src, destPath(remote, src), mode)
+			total.Add(v)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return total, firstErr
+}
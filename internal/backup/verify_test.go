@@ -0,0 +1,64 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wcx0206/hermes/internal/config"
+	"github.com/wcx0206/hermes/internal/rclone"
+)
+
+// lossyTransport 的 Copy 不传输任何文件，用于模拟校验失败
+type lossyTransport struct {
+	*rclone.Local
+}
+
+func (lossyTransport) Copy(context.Context, string, string, rclone.TransferOptions) (rclone.Stats, error) {
+	return rclone.Stats{}, nil
+}
+
+func TestRunProjectVerifyRecorded(t *testing.T) {
+	src, bucket := t.TempDir(), t.TempDir()
+	writeFiles(t, src, "a", "b", "c")
+	cfg, project := localProject(t, "copy", bucket, []string{src}, "    verify: hash\n")
+
+	result, err := NewRunner(nil, nil).RunProject(context.Background(), cfg, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := NewHistoryRecord(project.Name, TriggerManual, time.Now(), result, err)
+	if rec.Verify == nil || rec.Verify.Checked != 3 || !rec.Verify.OK() {
+		t.Errorf("record verify = %+v, want 3 checked files", rec.Verify)
+	}
+	if rec.Units[0].Verify == nil || rec.Units[0].Verify.Checked != 3 {
+		t.Errorf("unit verify = %+v, want 3 checked files", rec.Units[0].Verify)
+	}
+
+	factory := func(*config.Config, config.RcloneRemote, *zap.Logger) (rclone.Transport, error) {
+		return lossyTransport{rclone.NewLocalTransport("")}, nil
+	}
+	writeFiles(t, src, "d")
+	result, err = NewRunner(factory, nil).RunProject(context.Background(), cfg, project)
+	var verr *VerifyError
+	if !errors.As(err, &verr) {
+		t.Fatalf("err = %v, want *VerifyError", err)
+	}
+	rec = NewHistoryRecord(project.Name, TriggerManual, time.Now(), result, err)
+	if rec.Status != RunFailed || rec.Verify == nil || rec.Verify.Missing != 1 || rec.Verify.Checked != 4 {
+		t.Errorf("record = %s with verify %+v, want failed with 1 missing of 4", rec.Status, rec.Verify)
+	}
+	if len(rec.Verify.Failed) != 1 || rec.Verify.Failed[0] != "d" {
+		t.Errorf("failed files = %q, want [d]", rec.Verify.Failed)
+	}
+}
+
+func TestHistoryRecordWithoutVerify(t *testing.T) {
+	rec := NewHistoryRecord("p", TriggerCron, time.Now(), &RunResult{Units: []UnitResult{{Status: UnitOK}}}, nil)
+	if rec.Verify != nil || rec.Units[0].Verify != nil {
+		t.Errorf("verify recorded without verification: %+v", rec)
+	}
+}
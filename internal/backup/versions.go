@@ -0,0 +1,57 @@
+package backup
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wcx0206/hermes/internal/config"
+	"github.com/wcx0206/hermes/internal/rclone"
+)
+
+// 版本目录名使用的 UTC 时间戳格式，按字典序即按时间排序
+const versionLayout = "20060102T150405Z"
+
+func versionsRoot(project *config.Project, remote config.RcloneRemote) string {
+	dir := project.Versions.Dir
+	if dir == "" {
+		dir = config.DefaultVersionsDir
+	}
+	return path.Join(remote.Bucket, dir)
+}
+
+// countArchived 返回本次运行移动到版本目录中的文件数，目录不存在时为 0
+func countArchived(ctx context.Context, client rclone.Transport, backupDir string) int64 {
+	info, err := client.Size(ctx, backupDir)
+	if err != nil {
+		return 0
+	}
+	return info.Count
+}
+
+// pruneVersions 删除超过保留期的版本目录，返回删除的目录数
+func pruneVersions(ctx context.Context, client rclone.Transport, root string, keepDays int, now time.Time, logger *zap.Logger) (int, error) {
+	entries, err := client.List(ctx, root, false)
+	if err != nil {
+		return 0, err
+	}
+	cutoff := now.AddDate(0, 0, -keepDays)
+	pruned := 0
+	for _, e := range entries {
+		if !e.IsDir {
+			continue
+		}
+		ts, err := time.Parse(versionLayout, path.Base(e.Path))
+		if err != nil || !ts.Before(cutoff) {
+			continue
+		}
+		if err := client.Delete(ctx, path.Join(root, e.Path)); err != nil {
+			return pruned, err
+		}
+		logger.Info("pruned old versions", zap.String("dir", path.Join(root, e.Path)))
+		pruned++
+	}
+	return pruned, nil
+}
@@ -0,0 +1,74 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wcx0206/hermes/internal/rclone"
+)
+
+func TestRunProjectSyncVersioned(t *testing.T) {
+	root, bucket := t.TempDir(), t.TempDir()
+	data := filepath.Join(root, "data")
+	writeFiles(t, data, "keep", "change", "remove")
+	cfg, project := localProject(t, "sync-versioned", bucket, []string{data}, "")
+	runner := NewRunner(nil, nil)
+	if _, err := runner.RunProject(context.Background(), cfg, project); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(data, "change"), []byte("new content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(data, "remove")); err != nil {
+		t.Fatal(err)
+	}
+	result, err := runner.RunProject(context.Background(), cfg, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Archived != 2 {
+		t.Errorf("archived = %d, want 2", result.Archived)
+	}
+
+	// 被覆盖与删除的文件移动到 <bucket>/.hermes-versions/<时间戳>/<dest>/
+	versions, err := os.ReadDir(filepath.Join(bucket, ".hermes-versions"))
+	if err != nil || len(versions) != 1 {
+		t.Fatalf("version dirs = %v, %v, want one", versions, err)
+	}
+	if _, err := time.Parse(versionLayout, versions[0].Name()); err != nil {
+		t.Errorf("version dir %q is not a timestamp", versions[0].Name())
+	}
+	assertFiles(t, filepath.Join(bucket, ".hermes-versions", versions[0].Name()), "data/change", "data/remove")
+	old, err := os.ReadFile(filepath.Join(bucket, ".hermes-versions", versions[0].Name(), "data/change"))
+	if err != nil || string(old) != "change" {
+		t.Errorf("archived content = %q, %v, want the previous version", old, err)
+	}
+	assertFiles(t, filepath.Join(bucket, "data"), "keep", "change")
+}
+
+func TestPruneVersions(t *testing.T) {
+	root := t.TempDir()
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	for _, d := range []time.Time{now.AddDate(0, 0, -40), now.AddDate(0, 0, -31), now.AddDate(0, 0, -29), now} {
+		writeFiles(t, filepath.Join(root, "versions", d.Format(versionLayout)), "f")
+	}
+	writeFiles(t, filepath.Join(root, "versions", "not-a-version"), "f")
+
+	pruned, err := pruneVersions(context.Background(), rclone.NewLocalTransport(root), "versions", 30, now, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned != 2 {
+		t.Errorf("pruned %d dirs, want 2", pruned)
+	}
+	assertFiles(t, filepath.Join(root, "versions"),
+		now.AddDate(0, 0, -29).Format(versionLayout)+"/f",
+		now.Format(versionLayout)+"/f",
+		"not-a-version/f")
+}
@@ -0,0 +1,110 @@
+package backup
+
+import (
+	"context"
+	"hash/fnv"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wcx0206/hermes/internal/config"
+)
+
+// 无法使用 inotify 时轮询源路径的间隔
+const watchPollInterval = 10 * time.Second
+
+// watch 监听项目的源路径，最后一次变化后静止 quiet_period 时调用 job，
+// 两次调用至少间隔 min_interval。job 执行期间的变化会在结束后再次触发。
+func (s *CronServer) watch(ctx context.Context, p *config.Project, job func(time.Time, string)) {
+	logger := s.logger.With(zap.String("project", p.Name))
+	paths := make([]string, 0, len(p.SourcePaths))
+	for _, src := range p.SourcePaths {
+		paths = append(paths, src.Path)
+	}
+	changes, err := watchPaths(ctx, paths, logger)
+	if err != nil {
+		logger.Error("failed to watch source paths, only cron will trigger backups", zap.Error(err))
+		return
+	}
+	logger.Info("watching source paths",
+		zap.Strings("paths", paths),
+		zap.Duration("quiet_period", p.Watch.QuietPeriod),
+		zap.Duration("min_interval", p.Watch.MinInterval))
+
+	timer := time.NewTimer(0)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	var last time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-changes:
+			timer.Reset(p.Watch.QuietPeriod)
+		case <-timer.C:
+			if wait := p.Watch.MinInterval - time.Since(last); !last.IsZero() && wait > 0 {
+				logger.Debug("source changed, waiting for min_interval", zap.Duration("wait", wait))
+				timer.Reset(wait)
+				continue
+			}
+			last = time.Now()
+			logger.Info("source changed, starting backup")
+			job(last, TriggerWatch)
+		}
+	}
+}
+
+// notify 非阻塞地发送变化通知，未处理的通知合并为一个
+func notify(changes chan<- struct{}) {
+	select {
+	case changes <- struct{}{}:
+	default:
+	}
+}
+
+// pollPaths 定期比较源路径下所有文件的路径、大小与修改时间，发生变化时发送通知
+func pollPaths(ctx context.Context, paths []string) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	go func() {
+		ticker := time.NewTicker(watchPollInterval)
+		defer ticker.Stop()
+		prev := fingerprint(paths)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if cur := fingerprint(paths); cur != prev {
+				prev = cur
+				notify(changes)
+			}
+		}
+	}()
+	return changes
+}
+
+func fingerprint(paths []string) uint64 {
+	h := fnv.New64a()
+	for _, root := range paths {
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			h.Write([]byte(path))
+			h.Write([]byte(strconv.FormatInt(info.Size(), 10)))
+			h.Write([]byte(strconv.FormatInt(info.ModTime().UnixNano(), 10)))
+			return nil
+		})
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,159 @@
+//go:build linux
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+
+	"go.uber.org/zap"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_ATTRIB | syscall.IN_CLOSE_WRITE |
+	syscall.IN_DELETE | syscall.IN_DELETE_SELF | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_MOVE_SELF
+
+// watchedDir 是一个被监听的目录，tree 为 false 时只是源文件所在的目录
+type watchedDir struct {
+	path string
+	tree bool
+}
+
+// inotifyWatcher 递归监听源目录，源为文件时监听所在目录并只关注该文件
+type inotifyWatcher struct {
+	// fd 只用于添加监听；调用 file.Fd() 会把 fd 设回阻塞模式，使 Close 无法中断 Read
+	fd      int
+	file    *os.File
+	dirs    map[int32]watchedDir
+	files   map[string]struct{} // 作为源的文件
+	changes chan struct{}
+	logger  *zap.Logger
+}
+
+// watchPaths 使用 inotify 监听源路径，初始化失败（如超出 max_user_watches）时退回轮询
+func watchPaths(ctx context.Context, paths []string, logger *zap.Logger) (<-chan struct{}, error) {
+	w, err := newInotifyWatcher(paths, logger)
+	if err != nil {
+		logger.Warn("inotify unavailable, polling source paths", zap.Duration("interval", watchPollInterval), zap.Error(err))
+		return pollPaths(ctx, paths), nil
+	}
+	go func() {
+		<-ctx.Done()
+		w.file.Close()
+	}()
+	go w.run()
+	return w.changes, nil
+}
+
+func newInotifyWatcher(paths []string, logger *zap.Logger) (*inotifyWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify init: %w", err)
+	}
+	w := &inotifyWatcher{
+		// 非阻塞的 fd 由 runtime poller 管理，Close 会使阻塞的 Read 返回
+		fd:      fd,
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		dirs:    make(map[int32]watchedDir),
+		files:   make(map[string]struct{}),
+		changes: make(chan struct{}, 1),
+		logger:  logger,
+	}
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			w.file.Close()
+			return nil, err
+		}
+		if info.IsDir() {
+			err = w.addTree(p)
+		} else {
+			w.files[p] = struct{}{}
+			err = w.add(filepath.Dir(p), false)
+		}
+		if err != nil {
+			w.file.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+func (w *inotifyWatcher) add(dir string, tree bool) error {
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, inotifyMask)
+	if err != nil {
+		return fmt.Errorf("watch %s: %w", dir, err)
+	}
+	// 同一目录重复添加时 inotify 返回相同的 wd
+	w.dirs[int32(wd)] = watchedDir{path: dir, tree: tree || w.dirs[int32(wd)].tree}
+	return nil
+}
+
+// addTree 监听 root 及其下所有目录
+func (w *inotifyWatcher) addTree(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// 遍历期间被删除的目录不影响其余目录
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return w.add(path, true)
+	})
+}
+
+func (w *inotifyWatcher) run() {
+	defer w.file.Close()
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				w.logger.Warn("inotify read failed, stopped watching", zap.Error(err))
+			}
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			name := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+int(ev.Len)]
+			off += syscall.SizeofInotifyEvent + int(ev.Len)
+			w.handle(ev, string(bytes.TrimRight(name, "\x00")))
+		}
+	}
+}
+
+func (w *inotifyWatcher) handle(ev *syscall.InotifyEvent, name string) {
+	if ev.Mask&syscall.IN_Q_OVERFLOW != 0 {
+		notify(w.changes)
+		return
+	}
+	if ev.Mask&syscall.IN_IGNORED != 0 {
+		delete(w.dirs, ev.Wd)
+		return
+	}
+	dir, ok := w.dirs[ev.Wd]
+	if !ok {
+		return
+	}
+	path := filepath.Join(dir.path, name)
+	if _, isFile := w.files[path]; !isFile && !dir.tree {
+		// 源文件所在的目录中的其他文件
+		return
+	}
+	if ev.Mask&syscall.IN_ISDIR != 0 && ev.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 {
+		if err := w.addTree(path); err != nil {
+			w.logger.Warn("failed to watch new directory", zap.String("path", path), zap.Error(err))
+		}
+	}
+	notify(w.changes)
+}
@@ -0,0 +1,92 @@
+//go:build linux
+
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestWatchPathsNotifiesAndStops(t *testing.T) {
+	dir := t.TempDir()
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	changes, err := watchPaths(ctx, []string{dir}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	waitChange(t, changes)
+	// 新建的子目录同样被监听
+	time.Sleep(50 * time.Millisecond)
+	drain(changes)
+	if err := os.WriteFile(filepath.Join(dir, "sub", "f"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitChange(t, changes)
+
+	// 没有新事件时取消也必须让读取 inotify 的 goroutine 退出
+	cancel()
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("watcher goroutines still running: %d > %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchPathsIgnoresSiblingsOfSourceFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(src, []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes, err := watchPaths(ctx, []string{src}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "other"), []byte("b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+		t.Fatal("change of a sibling file was reported")
+	case <-time.After(200 * time.Millisecond):
+	}
+	if err := os.WriteFile(src, []byte("c"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitChange(t, changes)
+}
+
+func waitChange(t *testing.T, changes <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no change reported")
+	}
+}
+
+func drain(changes <-chan struct{}) {
+	for {
+		select {
+		case <-changes:
+		default:
+			return
+		}
+	}
+}
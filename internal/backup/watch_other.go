@@ -0,0 +1,14 @@
+//go:build !linux
+
+package backup
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// watchPaths 在非 Linux 系统上轮询源路径
+func watchPaths(ctx context.Context, paths []string, _ *zap.Logger) (<-chan struct{}, error) {
+	return pollPaths(ctx, paths), nil
+}
@@ -2,8 +2,14 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -25,51 +31,282 @@ func NewBackupCmd() *cobra.Command {
 
 	cmd.AddCommand(
 		newBackupRunCmd(opts),
+		newBackupVerifyCmd(opts),
+		newBackupDiffCmd(opts),
 	)
 	return cmd
 }
 
 func newBackupRunCmd(opts *backupOpts) *cobra.Command {
-	var projects string
+	var (
+		projects      string
+		skipSafeguard bool
+		wait          bool
+		noWait        bool
+	)
 	cmd := &cobra.Command{
 		Use:   "run",
 		Short: "Run backup now",
-		RunE: func(_ *cobra.Command, _ []string) error {
-			cfg, err := config.LoadConfig(opts.configPath)
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg, err := config.LoadFile(opts.configPath)
 			if err != nil {
 				return err
 			}
-			projectSet := make(map[string]struct{})
-			if projects != "" {
-				for _, name := range strings.Split(projects, ",") {
-					if trimmed := strings.TrimSpace(name); trimmed != "" {
-						projectSet[trimmed] = struct{}{}
-					}
-				}
+			projectList := selectProjects(cfg, projects)
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			runner := backup.NewRunner(backup.DefaultTransport, nil)
+			runner.SkipSafeguard = skipSafeguard
+			if runner.Quota, err = backup.NewQuota(cfg.Defaults.DailyQuota); err != nil {
+				return err
 			}
-			projectList := make([]config.Project, 0, len(cfg.Projects))
-			for _, p := range cfg.Projects {
-				// 如果没有指定项目，则全部添加
-				if len(projectSet) == 0 {
-					projectList = append(projectList, p)
+			runner.Slots = backup.NewSemaphore(cfg.Defaults.MaxProcesses)
+			history := backup.OpenHistory()
+			schedule := backup.OpenScheduleState()
+			failed := 0
+			for i := range projectList {
+				lock, err := lockProject(ctx, cmd.OutOrStdout(), projectList[i].Name, wait && !noWait)
+				if err != nil {
+					if errors.Is(err, context.Canceled) {
+						return err
+					}
+					// 被占用的项目计为失败，继续备份其余项目
+					failed++
+					fmt.Printf("Backup for project '%s' failed: %v\n", projectList[i].Name, err)
 					continue
 				}
-				if _, ok := projectSet[p.Name]; ok {
-					projectList = append(projectList, p)
-				}
-			}
-			ctx := context.Background()
-			for i := range projectList {
 				now := time.Now()
-				if err := backup.RunProject(ctx, cfg, &projectList[i]); err != nil {
-					return err
+				progress := make(chan backup.Progress, 16)
+				rendered := make(chan struct{})
+				go func() {
+					renderProgress(cmd.OutOrStdout(), progress, projectList[i].Concurrency > 1)
+					close(rendered)
+				}()
+				runner.Progress = progress
+				result, err := runner.RunProject(ctx, cfg, &projectList[i])
+				close(progress)
+				<-rendered
+				if uerr := lock.Unlock(); uerr != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to release lock of project '%s': %v\n", projectList[i].Name, uerr)
+				}
+				if herr := history.Append(backup.NewHistoryRecord(projectList[i].Name, backup.TriggerManual, now, result, err)); herr != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to record run history: %v\n", herr)
+				}
+				if err == nil {
+					// 手动运行备份了整个项目，成功同样计入每个定时任务 catch_up_older_than 的判断
+					for _, job := range projectList[i].Scheduled() {
+						if serr := schedule.Update(job.Key(), func(ps *backup.ProjectSchedule) { ps.LastSuccess = now }); serr != nil {
+							fmt.Fprintf(cmd.ErrOrStderr(), "Warning: failed to save schedule state: %v\n", serr)
+						}
+					}
 				}
 				cost := time.Since(now)
-				fmt.Printf("Backup for project '%s' completed successfully, cost '%s'\n", projectList[i].Name, cost)
+				if errors.Is(err, context.Canceled) {
+					printUnits(cmd.OutOrStdout(), result)
+					fmt.Printf("Backup for project '%s' cancelled after '%s'\n", projectList[i].Name, cost)
+					return err
+				}
+				if errors.Is(err, context.DeadlineExceeded) {
+					failed++
+					fmt.Printf("Backup for project '%s' timed out after '%s' (timeout %s)\n", projectList[i].Name, cost, projectList[i].Timeout)
+					printUnits(cmd.OutOrStdout(), result)
+					continue
+				}
+				if err != nil {
+					failed++
+					fmt.Printf("Backup for project '%s' failed, %d of %d units failed, cost '%s'\n",
+						projectList[i].Name, result.Failed(), len(result.Units), cost)
+					printUnits(cmd.OutOrStdout(), result)
+					continue
+				}
+				fmt.Printf("Backup for project '%s' completed successfully, cost '%s', transferred %s in %d files\n",
+					projectList[i].Name, cost, formatBytes(result.Stats.Bytes), result.Stats.Transfers)
+				printUnits(cmd.OutOrStdout(), result)
+				if projectList[i].Mode == "sync-versioned" {
+					fmt.Printf("  %d replaced or deleted files archived as versions\n", result.Archived)
+				}
+				if projectList[i].Verify != "" {
+					fmt.Printf("  verified %d files (%s)\n", result.Verify.Checked, projectList[i].Verify)
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d project(s) failed", failed)
 			}
 			return nil
 		},
 	}
 	cmd.Flags().StringVar(&projects, "projects", "", "Comma-separated list of projects to back up (default: all)")
+	cmd.Flags().BoolVar(&skipSafeguard, "skip-safeguard", false, "Run sync even if planned deletions/modifications exceed the safeguard thresholds")
+	cmd.Flags().BoolVar(&wait, "wait", false, "Wait for a project that is being backed up by another process")
+	cmd.Flags().BoolVar(&noWait, "no-wait", false, "Fail if a project is being backed up by another process (default)")
+	cmd.MarkFlagsMutuallyExclusive("wait", "no-wait")
 	return cmd
 }
+
+// lockProject 获取项目锁，被占用且 wait 为 true 时提示持有者并等待
+func lockProject(ctx context.Context, w io.Writer, project string, wait bool) (*backup.ProjectLock, error) {
+	lock, err := backup.LockProject(ctx, project, false)
+	var locked *backup.LockedError
+	if !errors.As(err, &locked) {
+		return lock, err
+	}
+	if !wait {
+		return nil, fmt.Errorf("%w, use --wait to wait for it", err)
+	}
+	fmt.Fprintf(w, "Waiting for project '%s', it is being backed up by %s\n", project, locked.Holder)
+	return backup.LockProject(ctx, project, true)
+}
+
+func newBackupVerifyCmd(opts *backupOpts) *cobra.Command {
+	var (
+		projects string
+		mode     string
+	)
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Compare sources with their backups without transferring",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			switch mode {
+			case "", "size", "modtime", "hash":
+			default:
+				return fmt.Errorf("invalid mode %q, want size, modtime or hash", mode)
+			}
+			cfg, err := config.LoadFile(opts.configPath)
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			runner := backup.NewRunner(backup.DefaultTransport, nil)
+			failed := 0
+			for _, p := range selectProjects(cfg, projects) {
+				result, err := runner.VerifyProject(ctx, cfg, &p, mode)
+				if err != nil {
+					failed++
+					fmt.Fprintf(cmd.OutOrStdout(), "Project '%s' verification failed: %v\n", p.Name, err)
+				} else {
+					fmt.Fprintf(cmd.OutOrStdout(), "Project '%s' verified: %d files match\n", p.Name, result.Checked)
+				}
+				for _, f := range result.Failed {
+					fmt.Fprintf(cmd.OutOrStdout(), "  ! %s\n", f)
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d project(s) failed verification", failed)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&projects, "projects", "", "Comma-separated list of projects to verify (default: all)")
+	cmd.Flags().StringVar(&mode, "mode", "", "Comparison: size, modtime or hash (default: project verify setting, else modtime)")
+	return cmd
+}
+
+func newBackupDiffCmd(opts *backupOpts) *cobra.Command {
+	var (
+		remote string
+		output string
+	)
+	cmd := &cobra.Command{
+		Use:   "diff <project>",
+		Short: "Show files that are new, changed or deleted locally compared with the backup",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "text" && output != "json" {
+				return fmt.Errorf("invalid output %q, want text or json", output)
+			}
+			cfg, err := config.LoadFile(opts.configPath)
+			if err != nil {
+				return err
+			}
+			project, err := findProject(cfg, args[0])
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			runner := backup.NewRunner(backup.DefaultTransport, nil)
+			diffs, err := runner.DiffProject(ctx, cfg, project, remote)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if output == "json" {
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				return enc.Encode(map[string]any{"project": project.Name, "mode": project.Mode, "diffs": diffs})
+			}
+			for _, d := range diffs {
+				fmt.Fprintf(out, "%s -> %s:%s\n", d.Source, d.Remote, d.Dest)
+				for _, p := range d.New {
+					fmt.Fprintf(out, "  + %s\n", p)
+				}
+				for _, p := range d.Changed {
+					fmt.Fprintf(out, "  ~ %s\n", p)
+				}
+				for _, p := range d.Deleted {
+					fmt.Fprintf(out, "  - %s\n", p)
+				}
+				fmt.Fprintf(out, "  %d new, %d changed, %d deleted, %d unchanged\n",
+					len(d.New), len(d.Changed), len(d.Deleted), d.Unchanged)
+			}
+			if project.Mode == "copy" {
+				fmt.Fprintln(out, "Note: project uses copy mode, deleted files are kept on the remote")
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&remote, "remote", "", "Only compare with this remote (default: all remotes of the project)")
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format: text or json")
+	return cmd
+}
+
+// printUnits 逐行打印每个 (源, remote) 单元的结果
+func printUnits(w io.Writer, result *backup.RunResult) {
+	for _, u := range result.Units {
+		line := fmt.Sprintf("  %-7s %s -> %s:%s", u.Status, u.Source, u.Remote, u.Dest)
+		switch u.Status {
+		case backup.UnitOK:
+			line += fmt.Sprintf(", %s, %s in %d files", u.Duration.Round(time.Millisecond), formatBytes(u.Stats.Bytes), u.Stats.Transfers)
+		case backup.UnitFailed, backup.UnitTimedOut:
+			line += fmt.Sprintf(", %s, error: %v", u.Duration.Round(time.Millisecond), u.Err)
+		case backup.UnitCancelled:
+			line += fmt.Sprintf(", %s", u.Duration.Round(time.Millisecond))
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// selectProjects 返回逗号分隔的 names 中列出的项目，names 为空时返回全部项目
+func selectProjects(cfg *config.Config, names string) []config.Project {
+	projectSet := make(map[string]struct{})
+	if names != "" {
+		for _, name := range strings.Split(names, ",") {
+			if trimmed := strings.TrimSpace(name); trimmed != "" {
+				projectSet[trimmed] = struct{}{}
+			}
+		}
+	}
+	projectList := make([]config.Project, 0, len(cfg.Projects))
+	for _, p := range cfg.Projects {
+		// 如果没有指定项目，则全部添加
+		if len(projectSet) == 0 {
+			projectList = append(projectList, p)
+			continue
+		}
+		if _, ok := projectSet[p.Name]; ok {
+			projectList = append(projectList, p)
+		}
+	}
+	return projectList
+}
+
+// findProject 返回名为 name 的项目
+func findProject(cfg *config.Config, name string) (*config.Project, error) {
+	for i := range cfg.Projects {
+		if cfg.Projects[i].Name == name {
+			return &cfg.Projects[i], nil
+		}
+	}
+	return nil, fmt.Errorf("project %s not found", name)
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wcx0206/hermes/internal/backup"
+)
+
+func NewHistoryCmd() *cobra.Command {
+	var (
+		project string
+		since   string
+		failed  bool
+		output  string
+	)
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show recorded backup runs",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if output != "text" && output != "json" {
+				return fmt.Errorf("invalid output %q, want text or json", output)
+			}
+			filter := backup.HistoryFilter{Project: project, Failed: failed}
+			if since != "" {
+				age, err := parseAge(since)
+				if err != nil {
+					return err
+				}
+				filter.Since = time.Now().Add(-age)
+			}
+			recs, err := backup.OpenHistory().Query(filter)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if output == "json" {
+				if recs == nil {
+					recs = []backup.HistoryRecord{}
+				}
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				enc.SetEscapeHTML(false)
+				return enc.Encode(recs)
+			}
+			if len(recs) == 0 {
+				fmt.Fprintln(out, "No runs recorded")
+				return nil
+			}
+			tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tPROJECT\tTRIGGER\tSTART\tDURATION\tSTATUS\tTRANSFERRED\tUNITS")
+			for _, rec := range recs {
+				ok := 0
+				for _, u := range rec.Units {
+					if u.Status == backup.UnitOK {
+						ok++
+					}
+				}
+				trigger := rec.Trigger
+				if rec.Schedule != "" {
+					trigger += "/" + rec.Schedule
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s in %d files\t%d/%d ok\n",
+					rec.ID, rec.Project, trigger,
+					rec.Start.Local().Format(time.DateTime),
+					rec.Duration().Round(time.Second),
+					rec.Status,
+					formatBytes(rec.Bytes), rec.Transfers,
+					ok, len(rec.Units))
+			}
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+			for _, rec := range recs {
+				for _, u := range rec.Units {
+					if u.Error != "" {
+						fmt.Fprintf(out, "%s %s -> %s: %s\n", rec.ID, u.Source, u.Remote, u.Error)
+					}
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&project, "project", "", "Only show runs of this project")
+	cmd.Flags().StringVar(&since, "since", "", "Only show runs started within this period, e.g. 7d or 12h")
+	cmd.Flags().BoolVar(&failed, "failed", false, "Only show runs that did not succeed")
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format: text or json")
+
+	cmd.AddCommand(newHistoryPruneCmd())
+	return cmd
+}
+
+func newHistoryPruneCmd() *cobra.Command {
+	var olderThan string
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete recorded runs older than the given age",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			age, err := parseAge(olderThan)
+			if err != nil {
+				return err
+			}
+			n, err := backup.OpenHistory().Prune(time.Now().Add(-age))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Pruned %d run(s) older than %s\n", n, olderThan)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&olderThan, "older-than", "90d", "Age of the runs to delete, e.g. 30d or 720h")
+	return cmd
+}
+
+// parseAge 解析时间段，在 time.ParseDuration 的基础上支持以天为单位的 "7d"
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid period %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid period %q", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/wcx0206/hermes/internal/backup"
+)
+
+// renderProgress 在同一行刷新每个 project/remote 的传输进度。依次执行时切换 remote 即换行；
+// parallel 为 true 时多个 remote 同时传输，在同一行以精简格式显示所有 remote 的最新进度
+func renderProgress(w io.Writer, ch <-chan backup.Progress, parallel bool) {
+	current := ""
+	latest := make(map[string]backup.Progress)
+	var keys []string
+	for p := range ch {
+		key := p.Project + "/" + p.Remote
+		if parallel {
+			if _, ok := latest[key]; !ok {
+				keys = append(keys, key)
+			}
+			latest[key] = p
+			parts := make([]string, 0, len(keys))
+			for _, k := range keys {
+				s := latest[k]
+				parts = append(parts, fmt.Sprintf("[%s] %s / %s, files %d/%d, %s/s",
+					k, formatBytes(s.Bytes), formatBytes(s.TotalBytes), s.Transfers, s.TotalTransfers, formatBytes(int64(s.Speed))))
+			}
+			current = key
+			fmt.Fprintf(w, "\r\033[K%s", strings.Join(parts, " | "))
+			continue
+		}
+		if current != "" && current != key {
+			fmt.Fprintln(w)
+		}
+		current = key
+		fmt.Fprintf(w, "\r\033[K[%s] %s / %s, files %d/%d, checks %d, errors %d, %s/s, ETA %s",
+			key,
+			formatBytes(p.Bytes), formatBytes(p.TotalBytes),
+			p.Transfers, p.TotalTransfers,
+			p.Checks,
+			p.Errors,
+			formatBytes(int64(p.Speed)),
+			formatETA(p.ETA),
+		)
+	}
+	if current != "" {
+		fmt.Fprintln(w)
+	}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatETA(seconds float64) string {
+	if seconds <= 0 {
+		return "-"
+	}
+	return (time.Duration(seconds) * time.Second).String()
+}
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"os"
 	"sort"
-	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -52,7 +51,7 @@ func newProjectListCmd(opts *projectOpts) *cobra.Command {
 				return cfg.Projects[i].Name < cfg.Projects[j].Name
 			})
 			for _, p := range cfg.Projects {
-				fmt.Fprintf(cmd.OutOrStdout(), "- %s (cron=%s, sources=%v)\n", p.Name, p.Cron, p.SourcePaths)
+				fmt.Fprintf(cmd.OutOrStdout(), "- %s (cron=%s, sources=%s)\n", p.Name, p.Cron, formatSources(p.SourcePaths))
 				for _, r := range p.RcloneRemotes {
 					fmt.Fprintf(cmd.OutOrStdout(), "    - rclone remote: %s -> %s\n", r.Name, r.Bucket)
 				}
@@ -82,13 +81,13 @@ func newProjectAddCmd(opts *projectOpts) *cobra.Command {
 				}
 			}
 			if len(sourcePaths) == 0 {
-				sourcePaths = promptList(reader, "Source paths (comma separated)")
+				sourcePaths = promptList(reader, "Source paths (comma separated, path or path=dest)")
 				if len(sourcePaths) == 0 {
 					return errors.New("at least one source path is required")
 				}
 			}
 			if mode == "" {
-				mode = promptString(reader, "Mode (sync/copy)")
+				mode = promptString(reader, "Mode (sync/copy/sync-versioned)")
 			}
 			if len(rcloneRemotes) == 0 {
 				rcloneRemotes = promptRemotes(reader)
@@ -107,7 +106,7 @@ func newProjectAddCmd(opts *projectOpts) *cobra.Command {
 
 			replace := config.Project{
 				Name:          name,
-				SourcePaths:   sourcePaths,
+				SourcePaths:   parseSources(sourcePaths),
 				Cron:          cronExpr,
 				RcloneRemotes: rcloneRemotes,
 				Mode:          mode,
@@ -136,7 +135,7 @@ func newProjectAddCmd(opts *projectOpts) *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVar(&name, "name", "", "project name")
-	cmd.Flags().StringSliceVar(&sourcePaths, "source", nil, "source path (repeatable)")
+	cmd.Flags().StringSliceVar(&sourcePaths, "source", nil, "source path, optionally path=dest (repeatable)")
 	cmd.Flags().StringVar(&cronExpr, "cron", "", "cron expression (optional)")
 	return cmd
 }
@@ -210,10 +209,10 @@ func newProjectUpdateCmd(opts *projectOpts) *cobra.Command {
 			if v := promptDefault(reader, "Project name", project.Name); v != "" {
 				project.Name = v
 			}
-			if sources := promptDefault(reader, "Source paths (comma separated)", strings.Join(project.SourcePaths, ",")); sources != "" {
-				project.SourcePaths = splitCSV(sources)
+			if sources := promptDefault(reader, "Source paths (comma separated, path or path=dest)", formatSources(project.SourcePaths)); sources != "" {
+				project.SourcePaths = parseSources(splitCSV(sources))
 			}
-			if v := promptDefault(reader, "Mode (sync/copy)", project.Mode); v != "" {
+			if v := promptDefault(reader, "Mode (sync/copy/sync-versioned)", project.Mode); v != "" {
 				project.Mode = v
 			}
 			if cron := promptDefault(reader, "Cron expression", project.Cron); cron != "" {
@@ -235,7 +234,7 @@ func newProjectUpdateCmd(opts *projectOpts) *cobra.Command {
 func printProject(p config.Project) {
 	fmt.Printf("Name: %s\n", p.Name)
 	fmt.Printf("Mode: %s\n", p.Mode)
-	fmt.Printf("Source Paths: %v\n", p.SourcePaths)
+	fmt.Printf("Source Paths: %s\n", formatSources(p.SourcePaths))
 	fmt.Printf("Cron: %s\n", p.Cron)
 	fmt.Printf("Rclone Remotes:\n")
 	for _, r := range p.RcloneRemotes {
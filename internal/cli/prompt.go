@@ -100,3 +100,23 @@ func promptRemotesWithDefaults(r *bufio.Reader, current []config.RcloneRemote) [
 	}
 	return result
 }
+
+// parseSources 把 "path" 或 "path=dest" 形式的输入转换为 SourcePath
+func parseSources(vals []string) []config.SourcePath {
+	var sources []config.SourcePath
+	for _, v := range vals {
+		if v == "" {
+			continue
+		}
+		sources = append(sources, config.ParseSourcePath(v))
+	}
+	return sources
+}
+
+func formatSources(sources []config.SourcePath) string {
+	parts := make([]string, len(sources))
+	for i, s := range sources {
+		parts[i] = s.String()
+	}
+	return strings.Join(parts, ",")
+}
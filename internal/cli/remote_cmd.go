@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/wcx0206/hermes/internal/backup"
+	"github.com/wcx0206/hermes/internal/config"
+	"github.com/wcx0206/hermes/internal/rclone"
+)
+
+type remoteOpts struct {
+	configPath string
+	remote     string
+}
+
+func NewRemoteCmd() *cobra.Command {
+	opts := &remoteOpts{}
+	cmd := &cobra.Command{
+		Use:   "remote",
+		Short: "Browse a project's backups on its remotes",
+	}
+	cmd.PersistentFlags().StringVar(&opts.configPath, "config", "config.yaml", "config file path")
+	cmd.PersistentFlags().StringVar(&opts.remote, "remote", "", "Only use this remote (default: all remotes of the project)")
+
+	cmd.AddCommand(
+		newRemoteLsCmd(opts),
+		newRemoteTreeCmd(opts),
+		newRemoteSizeCmd(opts),
+		newRemoteAboutCmd(opts),
+	)
+	return cmd
+}
+
+// remoteSession 解析项目的备份位置，并为每个 remote 复用同一个传输后端
+type remoteSession struct {
+	ctx        context.Context
+	cfg        *config.Config
+	project    *config.Project
+	transports map[string]rclone.Transport
+}
+
+func newRemoteSession(ctx context.Context, opts *remoteOpts, name string) (*remoteSession, []backup.Location, error) {
+	cfg, err := config.LoadFile(opts.configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	project, err := findProject(cfg, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	locs, err := backup.Locations(project, opts.remote)
+	if err != nil {
+		return nil, nil, err
+	}
+	s := &remoteSession{ctx: ctx, cfg: cfg, project: project, transports: make(map[string]rclone.Transport)}
+	return s, locs, nil
+}
+
+func (s *remoteSession) transport(remote config.RcloneRemote) (rclone.Transport, error) {
+	if t, ok := s.transports[remote.Name]; ok {
+		return t, nil
+	}
+	t, err := backup.DefaultTransport(s.cfg, remote, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.transports[remote.Name] = t
+	return t, nil
+}
+
+// runRemote 在可被 Ctrl-C 取消的上下文中对项目的每个备份位置执行 fn，单个位置失败时继续处理其余位置
+func runRemote(cmd *cobra.Command, opts *remoteOpts, name string, fn func(s *remoteSession, loc backup.Location, t rclone.Transport) error) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	s, locs, err := newRemoteSession(ctx, opts, name)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, loc := range locs {
+		t, err := s.transport(loc.Remote)
+		if err == nil {
+			err = fn(s, loc, t)
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(cmd.OutOrStdout(), "%s: %v\n", loc, err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d location(s) failed", failed)
+	}
+	return nil
+}
+
+func newRemoteLsCmd(opts *remoteOpts) *cobra.Command {
+	var recursive bool
+	cmd := &cobra.Command{
+		Use:   "ls <project>",
+		Short: "List the backed-up files of each source",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			return runRemote(cmd, opts, args[0], func(s *remoteSession, loc backup.Location, t rclone.Transport) error {
+				entries, err := t.List(s.ctx, loc.Path, recursive)
+				if err != nil {
+					return err
+				}
+				sortEntries(entries)
+				fmt.Fprintf(out, "%s:\n", loc)
+				for _, e := range entries {
+					name := e.Path
+					size := formatBytes(e.Size)
+					if e.IsDir {
+						name += "/"
+						size = "-"
+					}
+					fmt.Fprintf(out, "  %10s  %s  %s\n", size, e.ModTime.Local().Format("2006-01-02 15:04:05"), name)
+				}
+				return nil
+			})
+		},
+	}
+	cmd.Flags().BoolVarP(&recursive, "recursive", "R", false, "List subdirectories recursively")
+	return cmd
+}
+
+func newRemoteTreeCmd(opts *remoteOpts) *cobra.Command {
+	return &cobra.Command{
+		Use:   "tree <project>",
+		Short: "Show the backed-up files of each source as a tree",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			return runRemote(cmd, opts, args[0], func(s *remoteSession, loc backup.Location, t rclone.Transport) error {
+				entries, err := t.List(s.ctx, loc.Path, true)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "%s\n", loc)
+				printTree(out, entries)
+				return nil
+			})
+		},
+	}
+}
+
+func newRemoteSizeCmd(opts *remoteOpts) *cobra.Command {
+	return &cobra.Command{
+		Use:   "size <project>",
+		Short: "Show the number of objects and total size of each source",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			totals := make(map[string]*rclone.SizeInfo)
+			var order []string
+			err := runRemote(cmd, opts, args[0], func(s *remoteSession, loc backup.Location, t rclone.Transport) error {
+				info, err := t.Size(s.ctx, loc.Path)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "%s: %d objects, %s\n", loc, info.Count, formatBytes(info.Bytes))
+				total, ok := totals[loc.Remote.Name]
+				if !ok {
+					total = &rclone.SizeInfo{}
+					totals[loc.Remote.Name] = total
+					order = append(order, loc.Remote.Name)
+				}
+				total.Count += info.Count
+				total.Bytes += info.Bytes
+				return nil
+			})
+			for _, name := range order {
+				fmt.Fprintf(out, "Total on %s: %d objects, %s\n", name, totals[name].Count, formatBytes(totals[name].Bytes))
+			}
+			return err
+		},
+	}
+}
+
+func newRemoteAboutCmd(opts *remoteOpts) *cobra.Command {
+	return &cobra.Command{
+		Use:   "about <project>",
+		Short: "Show used and free space of each remote",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			seen := make(map[string]bool)
+			return runRemote(cmd, opts, args[0], func(s *remoteSession, loc backup.Location, t rclone.Transport) error {
+				// about 以 remote 为单位，同一 remote 的多个源只查询一次
+				if seen[loc.Remote.Name] {
+					return nil
+				}
+				seen[loc.Remote.Name] = true
+				usage, err := t.About(s.ctx, loc.Remote.Bucket)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "%s:%s\n", loc.Remote.Name, loc.Remote.Bucket)
+				printUsage(out, "Total", usage.Total, true)
+				printUsage(out, "Used", usage.Used, true)
+				printUsage(out, "Free", usage.Free, true)
+				printUsage(out, "Trashed", usage.Trashed, true)
+				printUsage(out, "Other", usage.Other, true)
+				printUsage(out, "Objects", usage.Objects, false)
+				return nil
+			})
+		},
+	}
+}
+
+func printUsage(w io.Writer, label string, v *int64, bytes bool) {
+	if v == nil {
+		return
+	}
+	if bytes {
+		fmt.Fprintf(w, "  %-8s %s\n", label+":", formatBytes(*v))
+	} else {
+		fmt.Fprintf(w, "  %-8s %d\n", label+":", *v)
+	}
+}
+
+func sortEntries(entries []rclone.Entry) {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+}
+
+// printTree 按目录层级打印递归列出的条目，条目路径以 / 分隔
+func printTree(w io.Writer, entries []rclone.Entry) {
+	children := make(map[string][]rclone.Entry)
+	for _, e := range entries {
+		parent := ""
+		if i := strings.LastIndex(e.Path, "/"); i >= 0 {
+			parent = e.Path[:i]
+		}
+		children[parent] = append(children[parent], e)
+	}
+	var walk func(dir, prefix string)
+	walk = func(dir, prefix string) {
+		items := children[dir]
+		sortEntries(items)
+		for i, e := range items {
+			branch, next := "├── ", "│   "
+			if i == len(items)-1 {
+				branch, next = "└── ", "    "
+			}
+			name := e.Path[strings.LastIndex(e.Path, "/")+1:]
+			if e.IsDir {
+				fmt.Fprintf(w, "%s%s%s/\n", prefix, branch, name)
+				walk(e.Path, prefix+next)
+			} else {
+				fmt.Fprintf(w, "%s%s%s (%s)\n", prefix, branch, name, formatBytes(e.Size))
+			}
+		}
+	}
+	walk("", "")
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/wcx0206/hermes/internal/backup"
+	"github.com/wcx0206/hermes/internal/config"
+)
+
+func NewRestoreCmd() *cobra.Command {
+	var (
+		configPath string
+		opts       backup.RestoreOptions
+	)
+	cmd := &cobra.Command{
+		Use:   "restore <project>",
+		Short: "Restore a project's data from one of its remotes",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.LoadFile(configPath)
+			if err != nil {
+				return err
+			}
+			project, err := findProject(cfg, args[0])
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			out := cmd.OutOrStdout()
+			progress := make(chan backup.Progress, 16)
+			rendered := make(chan struct{})
+			go func() {
+				renderProgress(out, progress, false)
+				close(rendered)
+			}()
+			runner := backup.NewRunner(backup.DefaultTransport, nil)
+			runner.Progress = progress
+			result, err := runner.RestoreProject(ctx, cfg, project, opts)
+			close(progress)
+			<-rendered
+			if errors.Is(err, backup.ErrTargetNotEmpty) {
+				return fmt.Errorf("%w, use --force to overwrite or --to to restore elsewhere", err)
+			}
+			if err != nil {
+				return err
+			}
+			for _, t := range result.Targets {
+				fmt.Fprintf(out, "  %s:%s -> %s\n", result.Remote, t.Source, t.Target)
+			}
+			if opts.DryRun {
+				fmt.Fprintf(out, "Dry run for project '%s': would restore %s in %d files\n",
+					project.Name, formatBytes(result.Stats.Bytes), result.Stats.Transfers)
+				return nil
+			}
+			fmt.Fprintf(out, "Restore for project '%s' completed successfully, restored %s in %d files\n",
+				project.Name, formatBytes(result.Stats.Bytes), result.Stats.Transfers)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&configPath, "config", "config.yaml", "config file path")
+	cmd.Flags().StringVar(&opts.Remote, "remote", "", "Remote to restore from (default: the project's first remote)")
+	cmd.Flags().StringVar(&opts.To, "to", "", "Restore into this directory instead of the original source paths")
+	cmd.Flags().StringArrayVar(&opts.Include, "include", nil, "Only restore files matching this pattern (repeatable)")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "Overwrite files in non-empty targets")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Show what would be restored without writing anything")
+	return cmd
+}
@@ -14,8 +14,9 @@ import (
 )
 
 type serverOpts struct {
-	binaryPath string // 备份服务的二进制文件路径
-	configPath string // 配置文件路径
+	binaryPath  string        // 备份服务的二进制文件路径
+	configPath  string        // 配置文件路径
+	stopTimeout time.Duration // 等待备份服务退出的时间
 }
 
 func NewServerCmd() *cobra.Command {
@@ -34,6 +35,8 @@ func NewServerCmd() *cobra.Command {
 
 	cmd.PersistentFlags().StringVar(&opts.configPath, "config", configPath, "config file path")
 	cmd.PersistentFlags().StringVar(&opts.binaryPath, "binary", backupBinaryPath, "backup server binary path")
+	// 需要大于 stop_grace_period，让正在执行的 rclone 有时间优雅退出
+	cmd.PersistentFlags().DurationVar(&opts.stopTimeout, "timeout", 30*time.Second, "time to wait for the backup server to exit before killing it")
 
 	cmd.AddCommand(newServerStartCmd(opts))
 	cmd.AddCommand(newServerStopCmd(opts))
@@ -87,7 +90,7 @@ func newServerStartCmd(opts *serverOpts) *cobra.Command {
 }
 
 // 停止 Backup Server
-func newServerStopCmd(_ *serverOpts) *cobra.Command {
+func newServerStopCmd(opts *serverOpts) *cobra.Command {
 	return &cobra.Command{
 		Use:   "stop",
 		Short: "Gracefully stop backup server",
@@ -105,7 +108,7 @@ func newServerStopCmd(_ *serverOpts) *cobra.Command {
 			if signalErr != nil && !errors.Is(signalErr, os.ErrProcessDone) {
 				return fmt.Errorf("send stop signal: %w", signalErr)
 			}
-			deadline := time.Now().Add(10 * time.Second)
+			deadline := time.Now().Add(opts.stopTimeout)
 			for {
 				// 判断进程退出过程是否超时
 				if time.Now().After(deadline) {
@@ -139,7 +142,7 @@ func newServerRestartCmd(opts *serverOpts) *cobra.Command {
 						return fmt.Errorf("send stop signal: %w", sigErr)
 					}
 				}
-				deadline := time.Now().Add(10 * time.Second)
+				deadline := time.Now().Add(opts.stopTimeout)
 				for {
 					if time.Now().After(deadline) {
 						return fmt.Errorf("backup server (pid=%d) did not exit in time", pid)
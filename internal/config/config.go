@@ -1,10 +1,19 @@
 package config
 
 import (
+	"cmp"
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/wcx0206/hermes/internal/rclone"
 )
 
 type Config struct {
@@ -22,25 +31,191 @@ type Defaults struct {
 	RcloneRemote string `yaml:"rclone_remote"`
 	Bucket       string `yaml:"bucket"`
 	Cron         string `yaml:"cron"`
-	Mode         string `yaml:"mode"` // sync or copy
+	Mode         string `yaml:"mode"` // sync, copy or sync-versioned
+	// 见 Project.Timezone
+	Timezone string `yaml:"timezone,omitempty"`
+	// 见 Project.Jitter 与 Project.Stagger
+	Jitter  time.Duration `yaml:"jitter,omitempty"`
+	Stagger time.Duration `yaml:"stagger,omitempty"`
+	// 见 Project.Trigger 与 Project.Watch
+	Trigger string `yaml:"trigger,omitempty"`
+	Watch   Watch  `yaml:"watch,omitempty"`
+	// 取消备份时等待 rclone 退出的时间，超时后强制终止，为空时使用 10s
+	StopGracePeriod time.Duration `yaml:"stop_grace_period,omitempty"`
+	Options         RcloneOptions `yaml:"options,omitempty"`
+	// 每天上传的字节数上限（如 50G），用尽后停止上传，次日恢复
+	DailyQuota string    `yaml:"daily_quota,omitempty"`
+	Versions   Versions  `yaml:"versions,omitempty"`
+	Safeguard  Safeguard `yaml:"safeguard,omitempty"`
+	Verify     string    `yaml:"verify,omitempty"` // size, modtime or hash, 为空时不校验
+	Retry      Retry     `yaml:"retry,omitempty"`
+	// 每个项目同时写入的 remote 数，为 0 时依次写入
+	Concurrency int `yaml:"concurrency,omitempty"`
+	// 所有项目同时运行的 rclone 传输数上限，为 0 时不限制
+	MaxProcesses int `yaml:"max_processes,omitempty"`
+	// 守护进程同时运行的定时备份任务数上限，超出的任务排队等待，为 0 时不限制
+	MaxConcurrentJobs int `yaml:"max_concurrent_jobs,omitempty"`
+	// 定时任务触发时同一项目上一次运行尚未结束的处理方式：skip (default) or queue
+	Overlap string `yaml:"overlap,omitempty"`
+	// 单次备份的最长时间，超时后取消，为 0 时不限制
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// 备份窗口的结束时间（HH:MM），见 Project.WindowEnd
+	WindowEnd string `yaml:"window_end,omitempty"`
+	// 运行记录保留的天数，0 表示永久保留
+	HistoryKeepDays int `yaml:"history_keep_days,omitempty"`
+	// 见 Project.CatchUp 与 Project.CatchUpOlderThan
+	CatchUp          string        `yaml:"catch_up,omitempty"`
+	CatchUpOlderThan time.Duration `yaml:"catch_up_older_than,omitempty"`
 }
 
 type Project struct {
 	Name          string         `yaml:"name"`
-	Mode          string         `yaml:"mode"` // sync or copy
-	SourcePaths   []string       `yaml:"source_paths"`
+	Mode          string         `yaml:"mode"` // sync, copy or sync-versioned
+	SourcePaths   []SourcePath   `yaml:"source_paths"`
 	Cron          string         `yaml:"cron"`
 	RcloneRemotes []RcloneRemote `yaml:"rclone_remotes"`
+	Options       RcloneOptions  `yaml:"options,omitempty"`
+	Versions      Versions       `yaml:"versions,omitempty"`
+	Safeguard     Safeguard      `yaml:"safeguard,omitempty"`
+	// 传输后比较源与目标的方式：size, modtime or hash，为空时不校验
+	Verify string `yaml:"verify,omitempty"`
+	Retry  Retry  `yaml:"retry,omitempty"`
+	// 同时写入的 remote 数，为 0 时使用 defaults.concurrency
+	Concurrency int `yaml:"concurrency,omitempty"`
+	// skip or queue，为空时使用 defaults.overlap
+	Overlap string `yaml:"overlap,omitempty"`
+	// 为 0 时使用 defaults.timeout
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// 备份窗口的结束时间（HH:MM）：运行在触发后第一个该时刻被取消，
+	// 因排队等原因到该时刻仍未开始的运行被跳过。为空时使用 defaults.window_end
+	WindowEnd string `yaml:"window_end,omitempty"`
+	// 守护进程停机期间错过计划运行时的处理：once (default) 启动后补执行一次，skip 不补执行
+	CatchUp string `yaml:"catch_up,omitempty"`
+	// 大于 0 时只在最近一次成功运行早于该时间时补执行
+	CatchUpOlderThan time.Duration `yaml:"catch_up_older_than,omitempty"`
+	// 计算 cron 与 window_end 使用的时区（如 Asia/Shanghai），为空时使用 defaults.timezone，
+	// 仍为空时使用服务器本地时区
+	Timezone string `yaml:"timezone,omitempty"`
+	// 每次定时运行前的随机延迟上限，为 0 时使用 defaults.jitter
+	Jitter time.Duration `yaml:"jitter,omitempty"`
+	// 大于 0 时把定时运行固定推迟 [0, stagger) 内由主机名与项目名决定的时间，
+	// 使多台主机上相同 cron 的项目错开执行。为 0 时使用 defaults.stagger
+	Stagger time.Duration `yaml:"stagger,omitempty"`
+	// 配置后守护进程按每个条目分别注册定时任务，不再使用项目本身的 cron
+	Schedules []Schedule `yaml:"schedules,omitempty"`
+	// cron (default) or watch：watch 时守护进程监听源路径，变化静止后按 watch 配置执行备份，
+	// cron 作为兜底仍然生效
+	Trigger string `yaml:"trigger,omitempty"`
+	Watch   Watch  `yaml:"watch,omitempty"`
+}
+
+// 错过的计划运行的处理方式
+const (
+	CatchUpOnce = "once"
+	CatchUpSkip = "skip"
+)
+
+// 定时任务重叠时的处理方式
+const (
+	OverlapSkip  = "skip"  // 跳过本次触发
+	OverlapQueue = "queue" // 等待上一次运行结束后执行
+)
+
+// Safeguard 限制一次 sync 计划删除与修改的文件数，超过任一阈值时放弃本次 sync。
+// 百分比相对于目标端现有文件数，0 表示不限制。
+type Safeguard struct {
+	MaxChanges       int     `yaml:"max_changes,omitempty"`
+	MaxChangePercent float64 `yaml:"max_change_percent,omitempty"`
+}
+
+func (g Safeguard) Enabled() bool {
+	return g.MaxChanges > 0 || g.MaxChangePercent > 0
+}
+
+// DefaultVersionsDir 是 sync-versioned 模式下旧版本文件在 bucket 中的默认目录
+const DefaultVersionsDir = ".hermes-versions"
+
+// Versions 配置 sync-versioned 模式：被覆盖或删除的文件移动到
+// <bucket>/<dir>/<时间戳>/ 下，并保留 keep_days 天（0 表示永久保留）
+type Versions struct {
+	Dir      string `yaml:"dir,omitempty"`
+	KeepDays int    `yaml:"keep_days,omitempty"`
 }
 
 type RcloneRemote struct {
-	Name   string `yaml:"name"`
-	Bucket string `yaml:"bucket"`
+	Name    string        `yaml:"name"`
+	Bucket  string        `yaml:"bucket"`
+	Type    string        `yaml:"type,omitempty"` // rclone (default) or local, local 时 bucket 为本地目录
+	Options RcloneOptions `yaml:"options,omitempty"`
+}
+
+// RcloneOptions 是传给 rclone 的调优参数，remote > project > defaults 逐级继承未设置的字段
+type RcloneOptions struct {
+	Include    []string `yaml:"include,omitempty"`
+	Exclude    []string `yaml:"exclude,omitempty"`
+	Checksum   *bool    `yaml:"checksum,omitempty"` // 指针以便子级用 false 关闭父级的 true
+	BwLimit    string   `yaml:"bwlimit,omitempty"`
+	BwSchedule string   `yaml:"bwlimit_schedule,omitempty"` // 如 "08:00 512k, 23:00 off"
+	TPSLimit   float64  `yaml:"tpslimit,omitempty"`
+	Transfers  int      `yaml:"transfers,omitempty"`
+	Checkers   int      `yaml:"checkers,omitempty"`
+	ExtraFlags []string `yaml:"extra_flags,omitempty"` // 原样追加到 rclone 命令行
+}
+
+// UseChecksum 返回是否用哈希代替修改时间判断文件是否变化
+func (o RcloneOptions) UseChecksum() bool {
+	return o.Checksum != nil && *o.Checksum
+}
+
+func (o RcloneOptions) check() error {
+	if o.Transfers < 0 || o.Checkers < 0 || o.TPSLimit < 0 {
+		return fmt.Errorf("options transfers, checkers and tpslimit must not be negative")
+	}
+	if o.BwSchedule != "" {
+		if _, err := rclone.ParseBwSchedule(o.BwSchedule); err != nil {
+			return fmt.Errorf("options bwlimit_schedule: %w", err)
+		}
+	}
+	return nil
+}
+
+// inherit 用 parent 填充 o 中未设置的字段
+func (o RcloneOptions) inherit(parent RcloneOptions) RcloneOptions {
+	if len(o.Include) == 0 {
+		o.Include = parent.Include
+	}
+	if len(o.Exclude) == 0 {
+		o.Exclude = parent.Exclude
+	}
+	if o.Checksum == nil {
+		o.Checksum = parent.Checksum
+	}
+	// 固定限速与带宽计划作为一个整体继承，子级设置任意一个即覆盖父级
+	if o.BwLimit == "" && o.BwSchedule == "" {
+		o.BwLimit = parent.BwLimit
+		o.BwSchedule = parent.BwSchedule
+	}
+	if o.TPSLimit == 0 {
+		o.TPSLimit = parent.TPSLimit
+	}
+	if o.Transfers == 0 {
+		o.Transfers = parent.Transfers
+	}
+	if o.Checkers == 0 {
+		o.Checkers = parent.Checkers
+	}
+	if len(o.ExtraFlags) == 0 {
+		o.ExtraFlags = parent.ExtraFlags
+	}
+	return o
 }
 
 func Load() (*Config, error) {
-	path := envOr("CONFIG_PATH", "config1.yaml")
+	return LoadFile(envOr("CONFIG_PATH", "config1.yaml"))
+}
 
+// LoadFile 读取、校验配置并填充默认值，用于执行备份；编辑配置请使用 LoadConfig
+func LoadFile(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("read config %s: %w", path, err)
@@ -65,9 +240,72 @@ func (c *Config) applyDefaults() {
 		if p.Cron == "" {
 			p.Cron = c.Defaults.Cron
 		}
+		if p.Timezone == "" {
+			p.Timezone = c.Defaults.Timezone
+		}
+		if p.Jitter == 0 {
+			p.Jitter = c.Defaults.Jitter
+		}
+		if p.Stagger == 0 {
+			p.Stagger = c.Defaults.Stagger
+		}
+		if p.Trigger == "" {
+			p.Trigger = c.Defaults.Trigger
+		}
+		if p.Trigger == "" {
+			p.Trigger = TriggerCron
+		}
+		p.Watch = p.Watch.inherit(c.Defaults.Watch).withDefaults()
 		if p.Mode == "" {
 			p.Mode = c.Defaults.Mode
 		}
+		p.Versions = c.versionsOf(p)
+		if p.Verify == "" {
+			p.Verify = c.Defaults.Verify
+		}
+		if p.Safeguard.MaxChanges == 0 {
+			p.Safeguard.MaxChanges = c.Defaults.Safeguard.MaxChanges
+		}
+		if p.Safeguard.MaxChangePercent == 0 {
+			p.Safeguard.MaxChangePercent = c.Defaults.Safeguard.MaxChangePercent
+		}
+		p.Retry = p.Retry.inherit(c.Defaults.Retry).withDefaults()
+		if p.Concurrency == 0 {
+			p.Concurrency = c.Defaults.Concurrency
+		}
+		if p.Overlap == "" {
+			p.Overlap = c.Defaults.Overlap
+		}
+		if p.Overlap == "" {
+			p.Overlap = OverlapSkip
+		}
+		if p.Timeout == 0 {
+			p.Timeout = c.Defaults.Timeout
+		}
+		if p.WindowEnd == "" {
+			p.WindowEnd = c.Defaults.WindowEnd
+		}
+		if p.CatchUp == "" {
+			p.CatchUp = c.Defaults.CatchUp
+		}
+		if p.CatchUp == "" {
+			p.CatchUp = CatchUpOnce
+		}
+		if p.CatchUpOlderThan == 0 {
+			p.CatchUpOlderThan = c.Defaults.CatchUpOlderThan
+		}
+		for j := range p.Schedules {
+			s := &p.Schedules[j]
+			if s.Name == "" {
+				s.Name = strconv.Itoa(j + 1)
+			}
+			if s.Cron == "" {
+				s.Cron = p.Cron
+			}
+			if s.Mode == "" {
+				s.Mode = p.Mode
+			}
+		}
 		if len(p.RcloneRemotes) == 0 && c.Defaults.RcloneRemote != "" {
 			p.RcloneRemotes = []RcloneRemote{
 				{
@@ -76,6 +314,11 @@ func (c *Config) applyDefaults() {
 				},
 			}
 		}
+		p.Options = p.Options.inherit(c.Defaults.Options)
+		for j := range p.RcloneRemotes {
+			r := &p.RcloneRemotes[j]
+			r.Options = r.Options.inherit(p.Options)
+		}
 	}
 }
 
@@ -83,8 +326,65 @@ func (c *Config) check() error {
 	if len(c.Projects) == 0 {
 		return nil
 	}
-	if c.Defaults.Mode != "sync" && c.Defaults.Mode != "copy" && c.Defaults.Mode != "" {
-		return fmt.Errorf("defaults mode must be 'sync' or 'copy'")
+	if !validMode(c.Defaults.Mode) {
+		return fmt.Errorf("defaults mode must be 'sync', 'copy' or 'sync-versioned'")
+	}
+	if c.Defaults.Versions.KeepDays < 0 {
+		return fmt.Errorf("defaults versions keep_days must not be negative")
+	}
+	if err := c.Defaults.Safeguard.check(); err != nil {
+		return fmt.Errorf("defaults: %w", err)
+	}
+	if !validVerify(c.Defaults.Verify) {
+		return fmt.Errorf("defaults verify must be 'size', 'modtime' or 'hash'")
+	}
+	if err := c.Defaults.Options.check(); err != nil {
+		return fmt.Errorf("defaults: %w", err)
+	}
+	if err := c.Defaults.Retry.check(); err != nil {
+		return fmt.Errorf("defaults: %w", err)
+	}
+	if c.Defaults.Concurrency < 0 || c.Defaults.MaxProcesses < 0 || c.Defaults.MaxConcurrentJobs < 0 {
+		return fmt.Errorf("defaults concurrency, max_processes and max_concurrent_jobs must not be negative")
+	}
+	if !validOverlap(c.Defaults.Overlap) {
+		return fmt.Errorf("defaults overlap must be 'skip' or 'queue'")
+	}
+	if c.Defaults.Timeout < 0 {
+		return fmt.Errorf("defaults timeout must not be negative")
+	}
+	if c.Defaults.Jitter < 0 || c.Defaults.Stagger < 0 {
+		return fmt.Errorf("defaults jitter and stagger must not be negative")
+	}
+	if !validTrigger(c.Defaults.Trigger) {
+		return fmt.Errorf("defaults trigger must be 'cron' or 'watch'")
+	}
+	if err := c.Defaults.Watch.check(); err != nil {
+		return fmt.Errorf("defaults: %w", err)
+	}
+	if c.Defaults.HistoryKeepDays < 0 {
+		return fmt.Errorf("defaults history_keep_days must not be negative")
+	}
+	if !validCatchUp(c.Defaults.CatchUp) || c.Defaults.CatchUpOlderThan < 0 {
+		return fmt.Errorf("defaults catch_up must be 'once' or 'skip' and catch_up_older_than must not be negative")
+	}
+	if c.Defaults.Timezone != "" {
+		if _, err := time.LoadLocation(c.Defaults.Timezone); err != nil {
+			return fmt.Errorf("defaults timezone: %w", err)
+		}
+	}
+	if c.Defaults.Cron != "" {
+		if _, err := parseSchedule(c.Defaults.Cron, c.Defaults.Timezone); err != nil {
+			return fmt.Errorf("defaults: %w", err)
+		}
+	}
+	if !validWindowEnd(c.Defaults.WindowEnd) {
+		return fmt.Errorf("defaults window_end must be HH:MM")
+	}
+	if c.Defaults.DailyQuota != "" {
+		if _, err := rclone.ParseSize(c.Defaults.DailyQuota); err != nil {
+			return fmt.Errorf("defaults daily_quota: %w", err)
+		}
 	}
 	pnames := make(map[string]struct{})
 	for _, p := range c.Projects {
@@ -96,9 +396,82 @@ func (c *Config) check() error {
 		} else {
 			pnames[p.Name] = struct{}{}
 		}
+		if !validMode(p.Mode) {
+			return fmt.Errorf("project %s: mode must be 'sync', 'copy' or 'sync-versioned'", p.Name)
+		}
+		if p.Versions.KeepDays < 0 {
+			return fmt.Errorf("project %s: versions keep_days must not be negative", p.Name)
+		}
+		if err := p.Safeguard.check(); err != nil {
+			return fmt.Errorf("project %s: %w", p.Name, err)
+		}
+		if !validVerify(p.Verify) {
+			return fmt.Errorf("project %s: verify must be 'size', 'modtime' or 'hash'", p.Name)
+		}
 		if len(p.SourcePaths) == 0 {
 			return fmt.Errorf("project %s: source_paths is required", p.Name)
 		}
+		if err := p.Options.check(); err != nil {
+			return fmt.Errorf("project %s: %w", p.Name, err)
+		}
+		if err := p.Retry.check(); err != nil {
+			return fmt.Errorf("project %s: %w", p.Name, err)
+		}
+		if p.Concurrency < 0 {
+			return fmt.Errorf("project %s: concurrency must not be negative", p.Name)
+		}
+		if !validOverlap(p.Overlap) {
+			return fmt.Errorf("project %s: overlap must be 'skip' or 'queue'", p.Name)
+		}
+		if p.Timeout < 0 {
+			return fmt.Errorf("project %s: timeout must not be negative", p.Name)
+		}
+		if p.Jitter < 0 || p.Stagger < 0 {
+			return fmt.Errorf("project %s: jitter and stagger must not be negative", p.Name)
+		}
+		if !validTrigger(p.Trigger) {
+			return fmt.Errorf("project %s: trigger must be 'cron' or 'watch'", p.Name)
+		}
+		if err := p.Watch.check(); err != nil {
+			return fmt.Errorf("project %s: %w", p.Name, err)
+		}
+		// 在注册定时任务前发现表达式与时区的错误，而不是在服务启动到一半时失败
+		if p.Timezone != "" {
+			if _, err := time.LoadLocation(p.Timezone); err != nil {
+				return fmt.Errorf("project %s: timezone: %w", p.Name, err)
+			}
+		}
+		// cron 可以为空：没有 cron 的项目只能手动或由文件变化触发，守护进程不为其注册定时任务
+		if p.Cron != "" {
+			if _, err := parseSchedule(p.Cron, cmp.Or(p.Timezone, c.Defaults.Timezone)); err != nil {
+				return fmt.Errorf("project %s: %w", p.Name, err)
+			}
+		}
+		if err := checkSchedules(&p, c.remotesOf(&p), &c.Defaults); err != nil {
+			return fmt.Errorf("project %s: %w", p.Name, err)
+		}
+		if !validWindowEnd(p.WindowEnd) {
+			return fmt.Errorf("project %s: window_end must be HH:MM", p.Name)
+		}
+		if !validCatchUp(p.CatchUp) || p.CatchUpOlderThan < 0 {
+			return fmt.Errorf("project %s: catch_up must be 'once' or 'skip' and catch_up_older_than must not be negative", p.Name)
+		}
+		for _, sp := range p.SourcePaths {
+			if sp.Path == "" {
+				return fmt.Errorf("project %s: source path is required", p.Name)
+			}
+			if sp.Destination() == "." && len(p.SourcePaths) == 1 {
+				// 唯一的源可以用 dest: . 写入 bucket 根目录，即按源划分子路径之前的布局
+				if c.usesVersions(&p) {
+					return fmt.Errorf("project %s: source %s: the bucket root cannot be the destination in sync-versioned mode", p.Name, sp.Path)
+				}
+			} else if err := checkDest(sp.Destination()); err != nil {
+				return fmt.Errorf("project %s: source %s: %w", p.Name, sp.Path, err)
+			}
+			if vdir := c.versionsOf(&p).Dir; pathsOverlap(sp.Destination(), vdir) {
+				return fmt.Errorf("project %s: source %s destination overlaps with versions dir %s", p.Name, sp.Path, vdir)
+			}
+		}
 		if len(p.RcloneRemotes) != 0 {
 			rnames := make(map[string]struct{})
 			for _, r := range p.RcloneRemotes {
@@ -113,12 +486,114 @@ func (c *Config) check() error {
 				if r.Bucket == "" {
 					return fmt.Errorf("project %s: rclone_remote bucket is required", p.Name)
 				}
+				if r.Type != "" && r.Type != "rclone" && r.Type != "local" {
+					return fmt.Errorf("project %s: rclone_remote %s type must be 'rclone' or 'local'", p.Name, r.Name)
+				}
+				// 相对路径会相对于守护进程的工作目录解析
+				if r.Type == "local" && !filepath.IsAbs(r.Bucket) {
+					return fmt.Errorf("project %s: rclone_remote %s bucket must be an absolute path for type 'local'", p.Name, r.Name)
+				}
+				if err := r.Options.check(); err != nil {
+					return fmt.Errorf("project %s: rclone_remote %s: %w", p.Name, r.Name, err)
+				}
 			}
 		}
 	}
+	return c.checkDestOverlap()
+}
+
+// checkDestOverlap 拒绝同一 remote 上相同或互相嵌套的目标路径，
+// 否则 sync 时一个源会删除另一个源上传的文件
+func (c *Config) checkDestOverlap() error {
+	type target struct {
+		project, source, path string
+	}
+	seen := make(map[string][]target)
+	for i := range c.Projects {
+		p := &c.Projects[i]
+		for _, r := range c.remotesOf(p) {
+			for _, sp := range p.SourcePaths {
+				t := target{project: p.Name, source: sp.Path, path: path.Join(r.Bucket, sp.Destination())}
+				for _, o := range seen[r.Name] {
+					if pathsOverlap(o.path, t.path) {
+						return fmt.Errorf("project %s: source %s destination %s:%s overlaps with source %s of project %s",
+							t.project, t.source, r.Name, t.path, o.source, o.project)
+					}
+				}
+				seen[r.Name] = append(seen[r.Name], t)
+			}
+		}
+	}
+	return nil
+}
+
+// usesVersions 判断项目或其某个 schedules 条目是否使用 sync-versioned 模式
+func (c *Config) usesVersions(p *Project) bool {
+	if cmp.Or(p.Mode, c.Defaults.Mode) == "sync-versioned" {
+		return true
+	}
+	return slices.ContainsFunc(p.Schedules, func(s Schedule) bool { return s.Mode == "sync-versioned" })
+}
+
+// versionsOf 返回项目生效的版本保留配置
+func (c *Config) versionsOf(p *Project) Versions {
+	v := p.Versions
+	if v.Dir == "" {
+		v.Dir = c.Defaults.Versions.Dir
+	}
+	if v.Dir == "" {
+		v.Dir = DefaultVersionsDir
+	}
+	v.Dir = strings.Trim(v.Dir, "/")
+	if v.KeepDays == 0 {
+		v.KeepDays = c.Defaults.Versions.KeepDays
+	}
+	return v
+}
+
+func (g Safeguard) check() error {
+	if g.MaxChanges < 0 || g.MaxChangePercent < 0 || g.MaxChangePercent > 100 {
+		return fmt.Errorf("safeguard max_changes must not be negative and max_change_percent must be within 0-100")
+	}
 	return nil
 }
 
+func validVerify(mode string) bool {
+	return mode == "" || mode == rclone.CompareSize || mode == rclone.CompareModTime || mode == rclone.CompareHash
+}
+
+func validCatchUp(s string) bool {
+	return s == "" || s == CatchUpOnce || s == CatchUpSkip
+}
+
+func validWindowEnd(s string) bool {
+	if s == "" {
+		return true
+	}
+	_, err := time.Parse(windowLayout, s)
+	return err == nil
+}
+
+func validOverlap(overlap string) bool {
+	return overlap == "" || overlap == OverlapSkip || overlap == OverlapQueue
+}
+
+func validMode(mode string) bool {
+	return mode == "" || mode == "sync" || mode == "copy" || mode == "sync-versioned"
+}
+
+// remotesOf 返回项目实际使用的 remote，未配置时回退到 defaults
+func (c *Config) remotesOf(p *Project) []RcloneRemote {
+	if len(p.RcloneRemotes) == 0 && c.Defaults.RcloneRemote != "" {
+		return []RcloneRemote{{Name: c.Defaults.RcloneRemote, Bucket: c.Defaults.Bucket}}
+	}
+	return p.RcloneRemotes
+}
+
+func pathsOverlap(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
 func envOr(key, def string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
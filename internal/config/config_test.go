@@ -0,0 +1,185 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// loadYAML 把 data 写入临时文件并用 LoadFile 读取
+func loadYAML(t *testing.T, data string) (*Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadFile(path)
+}
+
+func TestCheckRemotes(t *testing.T) {
+	tests := []struct {
+		name    string
+		remotes string
+		wantErr string
+	}{
+		{"rclone", `[{name: r, bucket: b}]`, ""},
+		{"absolute local", `[{name: disk, type: local, bucket: /mnt/backup}]`, ""},
+		{"relative local", `[{name: disk, type: local, bucket: backup}]`, "absolute path"},
+		{"unknown type", `[{name: r, type: s3, bucket: b}]`, "type must be"},
+		{"duplicate", `[{name: r, bucket: a}, {name: r, bucket: b}]`, "duplicate rclone_remote"},
+		{"missing bucket", `[{name: r}]`, "bucket is required"},
+	}
+	for _, tt := range tests {
+		_, err := loadYAML(t, `
+defaults: {cron: "0 1 * * *"}
+projects:
+  - name: p
+    source_paths: [/data/a]
+    rclone_remotes: `+tt.remotes+"\n")
+		checkErr(t, tt.name, err, tt.wantErr)
+	}
+}
+
+func TestCheckDestOverlap(t *testing.T) {
+	tests := []struct {
+		name     string
+		projects string
+		wantErr  string
+	}{
+		{"distinct basenames", `
+  - {name: a, source_paths: [/x/data, /y/conf]}
+  - {name: b, source_paths: [/z/logs]}`, ""},
+		{"same basename", `
+  - {name: a, source_paths: [/x/data, /y/data]}`, "overlaps with source /x/data"},
+		{"same basename in another project", `
+  - {name: a, source_paths: [/x/data]}
+  - {name: b, source_paths: [/y/data]}`, "overlaps with source /x/data of project a"},
+		{"nested dest", `
+  - {name: a, source_paths: [/x/data, {path: /y/conf, dest: data/conf}]}`, "overlaps"},
+		{"prefix is not nesting", `
+  - {name: a, source_paths: [/x/data, {path: /y/conf, dest: data2}]}`, ""},
+		{"different remotes", `
+  - {name: a, source_paths: [/x/data]}
+  - {name: b, source_paths: [/y/data], rclone_remotes: [{name: other, bucket: bk}]}`, ""},
+		{"different buckets", `
+  - {name: a, source_paths: [/x/data]}
+  - {name: b, source_paths: [/y/data], rclone_remotes: [{name: r, bucket: other}]}`, ""},
+		{"root dest of a single source", `
+  - {name: a, source_paths: [{path: /x/data, dest: .}]}`, ""},
+		{"root dest overlaps every other source", `
+  - {name: a, source_paths: [{path: /x/data, dest: .}]}
+  - {name: b, source_paths: [/y/logs]}`, "overlaps"},
+		{"root dest of one of several sources", `
+  - {name: a, source_paths: [{path: /x/data, dest: .}, /y/logs]}`, "invalid destination"},
+		{"root dest in sync-versioned mode", `
+  - {name: a, mode: sync-versioned, source_paths: [{path: /x/data, dest: .}]}`, "bucket root"},
+		{"dest escaping the bucket", `
+  - {name: a, source_paths: [{path: /x/data, dest: ../up}]}`, "invalid destination"},
+	}
+	for _, tt := range tests {
+		_, err := loadYAML(t, `
+defaults: {cron: "0 1 * * *", rclone_remote: r, bucket: bk}
+projects:`+tt.projects+"\n")
+		checkErr(t, tt.name, err, tt.wantErr)
+	}
+}
+
+func TestOptionsInherit(t *testing.T) {
+	cfg, err := loadYAML(t, `
+defaults:
+  cron: "0 1 * * *"
+  options: {exclude: ["*.tmp"], transfers: 8, bwlimit_schedule: "08:00 512k"}
+projects:
+  - name: p
+    source_paths: [/data/a]
+    options: {checksum: true, bwlimit: 2M}
+    rclone_remotes:
+      - {name: inherit, bucket: b}
+      - {name: override, bucket: c, options: {checksum: false, transfers: 2, exclude: ["*.log"]}}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inherit, override := cfg.Projects[0].RcloneRemotes[0].Options, cfg.Projects[0].RcloneRemotes[1].Options
+	if !inherit.UseChecksum() || inherit.Transfers != 8 || inherit.Exclude[0] != "*.tmp" {
+		t.Errorf("inherited options = %+v", inherit)
+	}
+	// 项目设置了 bwlimit，整体覆盖 defaults 的带宽计划
+	if inherit.BwLimit != "2M" || inherit.BwSchedule != "" {
+		t.Errorf("bandwidth = %q / %q, want 2M without schedule", inherit.BwLimit, inherit.BwSchedule)
+	}
+	if override.UseChecksum() || override.Transfers != 2 || override.Exclude[0] != "*.log" {
+		t.Errorf("overridden options = %+v", override)
+	}
+}
+
+func TestCheckCronOptional(t *testing.T) {
+	// 没有 cron 的项目只能手动备份，加载与保存都应接受
+	cfg, err := loadYAML(t, `
+projects:
+  - {name: a, source_paths: [/x/data], rclone_remotes: [{name: r, bucket: b}]}
+`)
+	checkErr(t, "no cron", err, "")
+	if err == nil {
+		if cfg.Projects[0].Cron != "" {
+			t.Errorf("cron = %q, want empty", cfg.Projects[0].Cron)
+		}
+		if err := SaveConfig(filepath.Join(t.TempDir(), "config.yaml"), cfg); err != nil {
+			t.Errorf("save config without cron: %v", err)
+		}
+	}
+
+	_, err = loadYAML(t, `
+projects:
+  - {name: a, cron: "61 * * * *", source_paths: [/x/data], rclone_remotes: [{name: r, bucket: b}]}
+`)
+	checkErr(t, "invalid cron", err, "invalid cron")
+
+	cfg, err = loadYAML(t, `
+defaults:
+  cron: "0 1 * * *"
+projects:
+  - {name: a, source_paths: [/x/data], rclone_remotes: [{name: r, bucket: b}]}
+`)
+	checkErr(t, "defaults cron", err, "")
+	if err == nil && cfg.Projects[0].Cron != "0 1 * * *" {
+		t.Errorf("cron = %q, want the defaults cron", cfg.Projects[0].Cron)
+	}
+
+	cfg, err = loadYAML(t, `
+projects:
+  - name: a
+    source_paths: [/x/data]
+    rclone_remotes: [{name: r, bucket: b}]
+    schedules: [{name: hourly, cron: "0 * * * *"}]
+`)
+	checkErr(t, "cron per schedule", err, "")
+	if err == nil {
+		if got := cfg.Projects[0].Scheduled(); len(got) != 1 || got[0].Key() != "a/hourly" {
+			t.Errorf("Scheduled() = %+v, want a single a/hourly job", got)
+		}
+	}
+
+	// schedules 条目只用于定时运行，必须能得到 cron
+	_, err = loadYAML(t, `
+projects:
+  - name: a
+    source_paths: [/x/data]
+    rclone_remotes: [{name: r, bucket: b}]
+    schedules: [{name: hourly}]
+`)
+	checkErr(t, "schedule without cron", err, "cron is required")
+}
+
+func checkErr(t *testing.T, name string, err error, want string) {
+	t.Helper()
+	switch {
+	case want == "" && err != nil:
+		t.Errorf("%s: unexpected error: %v", name, err)
+	case want != "" && err == nil:
+		t.Errorf("%s: expected error containing %q", name, want)
+	case want != "" && !strings.Contains(err.Error(), want):
+		t.Errorf("%s: error %q does not contain %q", name, err, want)
+	}
+}
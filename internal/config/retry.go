@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wcx0206/hermes/internal/rclone"
+)
+
+// 重试策略的默认值
+const (
+	DefaultRetryInitialBackoff = 30 * time.Second
+	DefaultRetryMaxBackoff     = 10 * time.Minute
+	DefaultRetryJitter         = 0.2
+)
+
+// Retry 配置传输失败后的重试：第 n 次重试前等待 initial_backoff*2^(n-1)（不超过 max_backoff），
+// 并按 jitter 比例随机浮动。只有分类在 retry_on 中的错误会被重试。
+type Retry struct {
+	// 包含首次执行在内的最多尝试次数，0 或 1 表示不重试
+	MaxAttempts    int           `yaml:"max_attempts,omitempty"`
+	InitialBackoff time.Duration `yaml:"initial_backoff,omitempty"`
+	MaxBackoff     time.Duration `yaml:"max_backoff,omitempty"`
+	// 0-1 之间，为 0 时使用 0.2
+	Jitter float64 `yaml:"jitter,omitempty"`
+	// transient, auth, quota, not_found or fatal，为空时只重试 transient
+	RetryOn []string `yaml:"retry_on,omitempty"`
+}
+
+// Retryable 判断分类为 c 的错误是否应当重试
+func (r Retry) Retryable(c rclone.Category) bool {
+	if len(r.RetryOn) == 0 {
+		return c == rclone.CategoryTransient
+	}
+	for _, s := range r.RetryOn {
+		if s == string(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff 返回第 attempt 次失败后、下一次尝试前的基础等待时间（未加抖动）
+func (r Retry) Backoff(attempt int) time.Duration {
+	d := r.InitialBackoff
+	for i := 1; i < attempt && d < r.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, r.MaxBackoff)
+}
+
+// inherit 用 parent 填充未设置的字段
+func (r Retry) inherit(parent Retry) Retry {
+	if r.MaxAttempts == 0 {
+		r.MaxAttempts = parent.MaxAttempts
+	}
+	if r.InitialBackoff == 0 {
+		r.InitialBackoff = parent.InitialBackoff
+	}
+	if r.MaxBackoff == 0 {
+		r.MaxBackoff = parent.MaxBackoff
+	}
+	if r.Jitter == 0 {
+		r.Jitter = parent.Jitter
+	}
+	if r.RetryOn == nil {
+		r.RetryOn = parent.RetryOn
+	}
+	return r
+}
+
+// withDefaults 填充内置默认值
+func (r Retry) withDefaults() Retry {
+	return r.inherit(Retry{
+		InitialBackoff: DefaultRetryInitialBackoff,
+		MaxBackoff:     DefaultRetryMaxBackoff,
+		Jitter:         DefaultRetryJitter,
+	})
+}
+
+func (r Retry) check() error {
+	if r.MaxAttempts < 0 || r.InitialBackoff < 0 || r.MaxBackoff < 0 {
+		return fmt.Errorf("retry max_attempts, initial_backoff and max_backoff must not be negative")
+	}
+	if r.Jitter < 0 || r.Jitter > 1 {
+		return fmt.Errorf("retry jitter must be within 0-1")
+	}
+	for _, s := range r.RetryOn {
+		if _, ok := rclone.ParseCategory(s); !ok {
+			return fmt.Errorf("retry retry_on: unknown category %q, want transient, auth, quota, not_found or fatal", s)
+		}
+	}
+	return nil
+}
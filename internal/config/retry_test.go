@@ -0,0 +1,63 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wcx0206/hermes/internal/rclone"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	r := Retry{InitialBackoff: 30 * time.Second, MaxBackoff: 5 * time.Minute}
+	for attempt, want := range map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		4:  4 * time.Minute,
+		5:  5 * time.Minute,
+		50: 5 * time.Minute,
+	} {
+		if got := r.Backoff(attempt); got != want {
+			t.Errorf("Backoff(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	def := Retry{}
+	if !def.Retryable(rclone.CategoryTransient) || def.Retryable(rclone.CategoryAuth) || def.Retryable(rclone.CategoryFatal) {
+		t.Error("default policy must retry only transient errors")
+	}
+	custom := Retry{RetryOn: []string{"quota", "not_found"}}
+	if custom.Retryable(rclone.CategoryTransient) || !custom.Retryable(rclone.CategoryQuota) || !custom.Retryable(rclone.CategoryNotFound) {
+		t.Error("retry_on is not honoured")
+	}
+}
+
+func TestRetryInherit(t *testing.T) {
+	cfg, err := loadYAML(t, `
+defaults:
+  cron: "0 1 * * *"
+  retry: {max_attempts: 3, initial_backoff: 1m, retry_on: [transient, quota]}
+projects:
+  - {name: a, source_paths: [/x/a], rclone_remotes: [{name: r, bucket: b}]}
+  - {name: b, source_paths: [/x/b], rclone_remotes: [{name: r, bucket: c}], retry: {max_attempts: 5}}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := cfg.Projects[0].Retry, cfg.Projects[1].Retry
+	if a.MaxAttempts != 3 || a.InitialBackoff != time.Minute || a.MaxBackoff != DefaultRetryMaxBackoff || a.Jitter != DefaultRetryJitter {
+		t.Errorf("inherited retry = %+v", a)
+	}
+	if b.MaxAttempts != 5 || len(b.RetryOn) != 2 {
+		t.Errorf("overridden retry = %+v", b)
+	}
+
+	_, err = loadYAML(t, `
+defaults: {cron: "0 1 * * *", retry: {retry_on: [flaky]}}
+projects:
+  - {name: a, source_paths: [/x/a], rclone_remotes: [{name: r, bucket: b}]}
+`)
+	checkErr(t, "unknown category", err, "unknown category")
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cronParser 接受标准的五段表达式、带秒字段的六段表达式，以及 @daily、@every 6h 等描述符
+var cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Schedule 解析项目的 cron 表达式，表达式按 timezone 所在时区计算
+func (p *Project) Schedule() (cron.Schedule, error) {
+	return parseSchedule(p.Cron, p.Timezone)
+}
+
+// Location 返回项目的时区，未配置 timezone 时为服务器本地时区
+func (p *Project) Location() *time.Location {
+	if p.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// parseSchedule 解析 spec，表达式自带 CRON_TZ= 前缀时以前缀为准
+func parseSchedule(spec, timezone string) (cron.Schedule, error) {
+	if spec == "" {
+		return nil, fmt.Errorf("cron is required")
+	}
+	sched, err := cronParser.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron %q: %w", spec, err)
+	}
+	if timezone == "" || strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		return sched, nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	if s, ok := sched.(*cron.SpecSchedule); ok {
+		s.Location = loc
+	}
+	return sched, nil
+}
+
+// Schedule 是项目的一个定时计划，可以只备份部分 remote，并使用不同的 cron 与 mode
+type Schedule struct {
+	// 为空时使用序号（从 1 开始）
+	Name string `yaml:"name,omitempty"`
+	// 为空时使用项目的 cron
+	Cron string `yaml:"cron,omitempty"`
+	// 为空时使用项目的 mode
+	Mode string `yaml:"mode,omitempty"`
+	// 备份的 remote 名称，为空时备份项目的所有 remote
+	Remotes []string `yaml:"remotes,omitempty"`
+}
+
+// ScheduledProject 是守护进程为项目注册的一个定时任务
+type ScheduledProject struct {
+	// schedules 条目的名称，项目未配置 schedules 时为空
+	ScheduleName string
+	// 按条目派生的项目，名称与原项目相同，因此与原项目共用项目锁与运行记录
+	Project
+}
+
+// Key 标识定时任务，用于保存计划状态与计算错开时间
+func (sp *ScheduledProject) Key() string {
+	if sp.ScheduleName == "" {
+		return sp.Name
+	}
+	return sp.Name + "/" + sp.ScheduleName
+}
+
+// Scheduled 返回项目的定时任务：每个 schedules 条目一个，未配置 schedules 时为项目本身
+func (p *Project) Scheduled() []ScheduledProject {
+	if len(p.Schedules) == 0 {
+		return []ScheduledProject{{Project: *p}}
+	}
+	jobs := make([]ScheduledProject, 0, len(p.Schedules))
+	for _, s := range p.Schedules {
+		d := *p
+		d.Schedules = nil
+		d.Cron = cmp.Or(s.Cron, p.Cron)
+		d.Mode = cmp.Or(s.Mode, p.Mode)
+		if len(s.Remotes) > 0 {
+			d.RcloneRemotes = nil
+			for _, r := range p.RcloneRemotes {
+				if slices.Contains(s.Remotes, r.Name) {
+					d.RcloneRemotes = append(d.RcloneRemotes, r)
+				}
+			}
+		}
+		jobs = append(jobs, ScheduledProject{ScheduleName: s.Name, Project: d})
+	}
+	return jobs
+}
+
+// checkSchedules 检查项目的 schedules，remotes 为项目实际使用的 remote
+func checkSchedules(p *Project, remotes []RcloneRemote, defaults *Defaults) error {
+	names := make(map[string]struct{})
+	for i, s := range p.Schedules {
+		name := cmp.Or(s.Name, strconv.Itoa(i+1))
+		if _, exists := names[name]; exists {
+			return fmt.Errorf("duplicate schedule name %s", name)
+		}
+		names[name] = struct{}{}
+		if !validMode(s.Mode) {
+			return fmt.Errorf("schedule %s: mode must be 'sync', 'copy' or 'sync-versioned'", name)
+		}
+		if _, err := parseSchedule(cmp.Or(s.Cron, p.Cron, defaults.Cron), cmp.Or(p.Timezone, defaults.Timezone)); err != nil {
+			return fmt.Errorf("schedule %s: %w", name, err)
+		}
+		for _, rn := range s.Remotes {
+			if !slices.ContainsFunc(remotes, func(r RcloneRemote) bool { return r.Name == rn }) {
+				return fmt.Errorf("schedule %s: unknown remote %s", name, rn)
+			}
+		}
+	}
+	return nil
+}
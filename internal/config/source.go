@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SourcePath 是一个备份源及其在 bucket 下的目标子路径。
+// YAML 中既可以写成字符串（目标为源路径的 basename），也可以写成 {path, dest}。
+type SourcePath struct {
+	Path string `yaml:"path"`
+	Dest string `yaml:"dest,omitempty"`
+}
+
+func (s *SourcePath) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		s.Path = node.Value
+		s.Dest = ""
+		return nil
+	}
+	type plain SourcePath
+	return node.Decode((*plain)(s))
+}
+
+func (s SourcePath) MarshalYAML() (interface{}, error) {
+	if s.Dest == "" {
+		return s.Path, nil
+	}
+	type plain SourcePath
+	return plain(s), nil
+}
+
+// Destination 返回源在 bucket 下的目标子路径
+func (s SourcePath) Destination() string {
+	if s.Dest != "" {
+		return path.Clean(strings.Trim(s.Dest, "/"))
+	}
+	return filepath.Base(filepath.Clean(s.Path))
+}
+
+func (s SourcePath) String() string {
+	if s.Dest == "" {
+		return s.Path
+	}
+	return s.Path + "=" + s.Dest
+}
+
+// ParseSourcePath 解析命令行中的 "path" 或 "path=dest" 形式
+func ParseSourcePath(val string) SourcePath {
+	p, dest, _ := strings.Cut(val, "=")
+	return SourcePath{Path: strings.TrimSpace(p), Dest: strings.TrimSpace(dest)}
+}
+
+func checkDest(dest string) error {
+	if dest == "" || dest == "." || dest == "/" || dest == ".." || strings.HasPrefix(dest, "../") {
+		return fmt.Errorf("invalid destination %q, set an explicit dest (only a project's single source may use dest: . for the bucket root)", dest)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// 项目的触发方式
+const (
+	TriggerCron  = "cron"  // 只按 cron 定时执行
+	TriggerWatch = "watch" // 源路径变化后执行，cron 仍然生效
+)
+
+// 文件变化触发的默认值
+const (
+	DefaultWatchQuietPeriod = 30 * time.Second
+	DefaultWatchMinInterval = 5 * time.Minute
+)
+
+// Watch 配置 trigger: watch 的去抖：源路径最后一次变化后静止 quiet_period 才开始备份，
+// 两次由变化触发的备份至少间隔 min_interval
+type Watch struct {
+	QuietPeriod time.Duration `yaml:"quiet_period,omitempty"`
+	MinInterval time.Duration `yaml:"min_interval,omitempty"`
+}
+
+// inherit 用 parent 填充未设置的字段
+func (w Watch) inherit(parent Watch) Watch {
+	if w.QuietPeriod == 0 {
+		w.QuietPeriod = parent.QuietPeriod
+	}
+	if w.MinInterval == 0 {
+		w.MinInterval = parent.MinInterval
+	}
+	return w
+}
+
+// withDefaults 填充内置默认值
+func (w Watch) withDefaults() Watch {
+	return w.inherit(Watch{QuietPeriod: DefaultWatchQuietPeriod, MinInterval: DefaultWatchMinInterval})
+}
+
+func (w Watch) check() error {
+	if w.QuietPeriod < 0 || w.MinInterval < 0 {
+		return fmt.Errorf("watch quiet_period and min_interval must not be negative")
+	}
+	return nil
+}
+
+func validTrigger(s string) bool {
+	return s == "" || s == TriggerCron || s == TriggerWatch
+}
@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+const windowLayout = "15:04"
+
+// WindowDeadline 返回 after 之后第一个 window_end 时刻（项目时区），未配置 window_end 时 ok 为 false
+func (p *Project) WindowDeadline(after time.Time) (deadline time.Time, ok bool) {
+	if p.WindowEnd == "" {
+		return time.Time{}, false
+	}
+	after = after.In(p.Location())
+	t, err := time.Parse(windowLayout, p.WindowEnd)
+	if err != nil {
+		return time.Time{}, false
+	}
+	deadline = time.Date(after.Year(), after.Month(), after.Day(), t.Hour(), t.Minute(), 0, 0, after.Location())
+	if !deadline.After(after) {
+		deadline = deadline.AddDate(0, 0, 1)
+	}
+	return deadline, true
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowDeadline(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skip(err)
+	}
+	tests := []struct {
+		name      string
+		windowEnd string
+		timezone  string
+		after     time.Time
+		want      time.Time
+	}{
+		{"later today", "06:00", "UTC",
+			time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC)},
+		{"tomorrow", "06:00", "UTC",
+			time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC), time.Date(2024, 3, 2, 6, 0, 0, 0, time.UTC)},
+		{"exactly at the end", "06:00", "UTC",
+			time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC), time.Date(2024, 3, 2, 6, 0, 0, 0, time.UTC)},
+		{"month boundary", "00:30", "UTC",
+			time.Date(2024, 2, 29, 23, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 30, 0, 0, time.UTC)},
+		// 22:00 UTC 已是上海的次日 06:00，窗口在上海时间的下一个 06:00 结束
+		{"project timezone", "06:00", "Asia/Shanghai",
+			time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC), time.Date(2024, 3, 3, 6, 0, 0, 0, shanghai)},
+		{"project timezone same day", "06:00", "Asia/Shanghai",
+			time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC), time.Date(2024, 3, 2, 6, 0, 0, 0, shanghai)},
+	}
+	for _, tt := range tests {
+		p := &Project{WindowEnd: tt.windowEnd, Timezone: tt.timezone}
+		got, ok := p.WindowDeadline(tt.after)
+		if !ok || !got.Equal(tt.want) {
+			t.Errorf("%s: WindowDeadline(%s) = %s, %v, want %s", tt.name, tt.after, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := (&Project{}).WindowDeadline(time.Now()); ok {
+		t.Error("WindowDeadline without window_end returned ok")
+	}
+}
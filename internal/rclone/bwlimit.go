@@ -0,0 +1,77 @@
+package rclone
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	bwEntryRe = regexp.MustCompile(`^((?:Mon|Tue|Wed|Thu|Fri|Sat|Sun)-)?([01]?[0-9]|2[0-3]):([0-5][0-9])$`)
+	sizeRe    = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)([bBkKmMgGtTpP]?)(?:i?[bB])?$`)
+)
+
+// ParseBwSchedule 把 "08:00 512k, 23:00 off" 形式的带宽计划转换为 rclone 的
+// timetable 语法 "08:00,512k 23:00,off"，时间前可以带 "Mon-" 这样的星期前缀
+func ParseBwSchedule(schedule string) (string, error) {
+	var slots []string
+	for _, entry := range strings.Split(schedule, ",") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return "", fmt.Errorf("invalid bandwidth schedule entry %q, want \"HH:MM rate\"", strings.TrimSpace(entry))
+		}
+		if !bwEntryRe.MatchString(fields[0]) {
+			return "", fmt.Errorf("invalid time %q in bandwidth schedule", fields[0])
+		}
+		if err := checkRate(fields[1]); err != nil {
+			return "", err
+		}
+		slots = append(slots, fields[0]+","+fields[1])
+	}
+	if len(slots) == 0 {
+		return "", fmt.Errorf("empty bandwidth schedule")
+	}
+	return strings.Join(slots, " "), nil
+}
+
+// checkRate 校验单个速率，支持 off 以及 rclone 的 上传:下载 写法
+func checkRate(rate string) error {
+	if rate == "off" {
+		return nil
+	}
+	for _, part := range strings.Split(rate, ":") {
+		if part == "off" {
+			continue
+		}
+		if _, err := ParseSize(part); err != nil {
+			return fmt.Errorf("invalid rate %q in bandwidth schedule", rate)
+		}
+	}
+	return nil
+}
+
+// ParseSize 解析 rclone 风格的大小，如 512k、10M、1.5G，单位按 1024 进制，无单位时为 KiB
+func ParseSize(s string) (int64, error) {
+	m := sizeRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+	mult := map[string]float64{
+		"b": 1,
+		"":  1 << 10,
+		"k": 1 << 10,
+		"m": 1 << 20,
+		"g": 1 << 30,
+		"t": 1 << 40,
+		"p": 1 << 50,
+	}[strings.ToLower(m[2])]
+	return int64(n * mult), nil
+}
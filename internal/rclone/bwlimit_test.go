@@ -0,0 +1,76 @@
+package rclone
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseBwSchedule(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"08:00 512k, 23:00 off", "08:00,512k 23:00,off"},
+		{"8:00 1M", "8:00,1M"},
+		{"Mon-00:00 10M:1M, Sat-09:30 off,", "Mon-00:00,10M:1M Sat-09:30,off"},
+		{"12:00 1.5G", "12:00,1.5G"},
+	}
+	for _, tt := range tests {
+		got, err := ParseBwSchedule(tt.in)
+		if err != nil {
+			t.Errorf("ParseBwSchedule(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBwSchedule(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseBwScheduleInvalid(t *testing.T) {
+	for _, in := range []string{
+		"",
+		" , ",
+		"08:00",
+		"08:00 1M 2M",
+		"24:00 1M",
+		"08:60 1M",
+		"Foo-08:00 1M",
+		"08:00 fast",
+		"08:00 1M:fast",
+	} {
+		if got, err := ParseBwSchedule(in); err == nil {
+			t.Errorf("ParseBwSchedule(%q) = %q, want error", in, got)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"10B", 10},
+		{"10", 10 << 10},
+		{"512k", 512 << 10},
+		{"10M", 10 << 20},
+		{"1.5G", 3 << 29},
+		{"2GiB", 2 << 30},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if err != nil {
+			t.Errorf("ParseSize(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTransferFlagsBwSchedule(t *testing.T) {
+	flags := transferFlags(TransferOptions{BwLimit: "1M", BwSchedule: "08:00 512k, 23:00 off"})
+	if !slices.Contains(flags, "--bwlimit=08:00,512k 23:00,off") || slices.Contains(flags, "--bwlimit=1M") {
+		t.Errorf("flags = %q, want the schedule to replace the fixed limit", flags)
+	}
+}
@@ -0,0 +1,142 @@
+package rclone
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"strings"
+	"syscall"
+)
+
+// Category 是传输错误的分类，用于决定是否重试
+type Category string
+
+const (
+	CategoryTransient Category = "transient" // 网络抖动、限流、服务端 5xx 等，重试可能成功
+	CategoryAuth      Category = "auth"      // 凭证无效、权限不足
+	CategoryQuota     Category = "quota"     // 存储空间或传输配额用尽
+	CategoryNotFound  Category = "not_found" // 源、bucket 或 remote 不存在
+	CategoryFatal     Category = "fatal"     // 参数错误等无法通过重试解决的错误
+)
+
+// Categories 是所有错误分类
+var Categories = []Category{CategoryTransient, CategoryAuth, CategoryQuota, CategoryNotFound, CategoryFatal}
+
+// 每个分类对应的哨兵错误，*Error 可通过 errors.Is 与之匹配
+var (
+	ErrTransient = errors.New("transient error")
+	ErrAuth      = errors.New("authentication error")
+	ErrQuota     = errors.New("quota error")
+	ErrNotFound  = errors.New("not found")
+	ErrFatal     = errors.New("fatal error")
+)
+
+var categoryErrors = map[Category]error{
+	CategoryTransient: ErrTransient,
+	CategoryAuth:      ErrAuth,
+	CategoryQuota:     ErrQuota,
+	CategoryNotFound:  ErrNotFound,
+	CategoryFatal:     ErrFatal,
+}
+
+// rclone 的退出码，见 https://rclone.org/docs/#exit-code
+const (
+	exitUsage        = 1
+	exitFileNotFound = 4
+	exitRetryError   = 5
+	exitFatalError   = 7
+)
+
+// 按顺序匹配 rclone 错误日志的关键字（小写），先匹配到的分类优先。
+// 不匹配裸的 HTTP 状态码，以免与日志中的字节数等数字混淆
+var categoryPatterns = []struct {
+	category Category
+	patterns []string
+}{
+	{CategoryAuth, []string{
+		"unauthorized", "forbidden", "access denied", "accessdenied",
+		"invalid_grant", "invalidaccesskeyid", "signaturedoesnotmatch", "token expired",
+		"couldn't find section in config", "didn't find section in config", "permission denied",
+	}},
+	{CategoryQuota, []string{
+		"quota", "insufficient storage", "insufficient_storage", "no space left",
+		"storage full", "max transfer limit reached",
+	}},
+	{CategoryNotFound, []string{
+		"directory not found", "object not found", "file not found", "bucket not found",
+		"nosuchbucket", "no such file or directory",
+	}},
+	{CategoryTransient, []string{
+		"timeout", "timed out", "connection reset", "connection refused", "broken pipe",
+		"no such host", "network is unreachable", "temporary failure", "unexpected eof",
+		"tls handshake", "too many requests", "rate limit", "internal error", "internalerror",
+		"service unavailable", "bad gateway", "gateway timeout", "slowdown",
+	}},
+}
+
+// classify 根据退出码与错误日志判断 rclone 失败的分类
+func classify(exitCode int, lines []string) Category {
+	switch exitCode {
+	case exitUsage, exitFatalError:
+		return CategoryFatal
+	case exitMaxTransfer:
+		return CategoryQuota
+	}
+	if c, ok := matchCategory(strings.ToLower(strings.Join(lines, "\n"))); ok {
+		return c
+	}
+	switch exitCode {
+	case exitDirNotFound, exitFileNotFound:
+		return CategoryNotFound
+	case exitRetryError:
+		return CategoryTransient
+	}
+	return CategoryFatal
+}
+
+func matchCategory(msg string) (Category, bool) {
+	for _, cp := range categoryPatterns {
+		for _, p := range cp.patterns {
+			if strings.Contains(msg, p) {
+				return cp.category, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Classify 返回 err 的分类：*Error 使用 rclone 失败时确定的分类，
+// 其余错误（例如本地后端的文件系统错误）按错误类型与内容判断
+func Classify(err error) Category {
+	var e *Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return CategoryFatal
+	case errors.As(err, &e) && e.Category != "":
+		return e.Category
+	case errors.Is(err, ErrMaxTransfer), errors.Is(err, syscall.ENOSPC), errors.Is(err, syscall.EDQUOT):
+		return CategoryQuota
+	case errors.Is(err, fs.ErrPermission):
+		return CategoryAuth
+	case errors.Is(err, fs.ErrNotExist):
+		return CategoryNotFound
+	case errors.Is(err, syscall.ETIMEDOUT), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EAGAIN):
+		return CategoryTransient
+	}
+	if c, ok := matchCategory(strings.ToLower(err.Error())); ok {
+		return c
+	}
+	return CategoryFatal
+}
+
+// ParseCategory 校验并返回名为 s 的分类
+func ParseCategory(s string) (Category, bool) {
+	for _, c := range Categories {
+		if string(c) == s {
+			return c, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,65 @@
+package rclone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"syscall"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name  string
+		code  int
+		lines []string
+		want  Category
+	}{
+		{"usage error", exitUsage, []string{"connection reset by peer"}, CategoryFatal},
+		{"fatal error", exitFatalError, nil, CategoryFatal},
+		{"max transfer", exitMaxTransfer, nil, CategoryQuota},
+		{"auth", exitRetryError, []string{"ERROR : AccessDenied: Access Denied"}, CategoryAuth},
+		{"auth before transient", exitRetryError, []string{"403 Forbidden", "timeout"}, CategoryAuth},
+		{"quota", exitRetryError, []string{"write: no space left on device"}, CategoryQuota},
+		{"not found by message", exitRetryError, []string{"NoSuchBucket: bucket does not exist"}, CategoryNotFound},
+		{"transient", exitRetryError, []string{"dial tcp: i/o timeout"}, CategoryTransient},
+		{"dir not found", exitDirNotFound, nil, CategoryNotFound},
+		{"file not found", exitFileNotFound, []string{"something odd"}, CategoryNotFound},
+		{"retry error", exitRetryError, []string{"failed to copy 503 bytes"}, CategoryTransient},
+		{"unknown", 9, nil, CategoryFatal},
+	}
+	for _, tt := range tests {
+		if got := classify(tt.code, tt.lines); got != tt.want {
+			t.Errorf("%s: classify(%d, %q) = %s, want %s", tt.name, tt.code, tt.lines, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want Category
+	}{
+		{nil, ""},
+		{&Error{Op: "sync", Category: CategoryAuth, Err: errors.New("exit status 5")}, CategoryAuth},
+		{fmt.Errorf("unit: %w", &Error{Op: "copy", Category: CategoryTransient, Err: errors.New("exit status 5")}), CategoryTransient},
+		{context.Canceled, CategoryFatal},
+		{fmt.Errorf("copy: %w", ErrMaxTransfer), CategoryQuota},
+		{&fs.PathError{Op: "write", Path: "/x", Err: syscall.ENOSPC}, CategoryQuota},
+		{&fs.PathError{Op: "open", Path: "/x", Err: fs.ErrPermission}, CategoryAuth},
+		{&fs.PathError{Op: "open", Path: "/x", Err: fs.ErrNotExist}, CategoryNotFound},
+		{syscall.ECONNRESET, CategoryTransient},
+		{errors.New("read: connection reset by peer"), CategoryTransient},
+		{errors.New("something odd"), CategoryFatal},
+	}
+	for _, tt := range tests {
+		if got := Classify(tt.err); got != tt.want {
+			t.Errorf("Classify(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+	// *Error 可按分类与哨兵错误匹配
+	if err := (&Error{Category: CategoryQuota, Err: errors.New("x")}); !errors.Is(err, ErrQuota) || errors.Is(err, ErrTransient) {
+		t.Error("errors.Is does not match *Error by category")
+	}
+}
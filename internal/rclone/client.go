@@ -1,54 +1,333 @@
 package rclone
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
 )
 
+// DefaultGracePeriod 是取消后等待 rclone 自行退出的默认时间，超时后强制终止
+const DefaultGracePeriod = 10 * time.Second
+
 type Client struct {
-	RemoteName string
+	RemoteName  string
+	GracePeriod time.Duration
+	logger      *zap.Logger
 }
 
+var _ Transport = (*Client)(nil)
+
 type Options struct {
-	RemoteName string
+	RemoteName  string
+	GracePeriod time.Duration // 取消后等待 rclone 退出的时间，为 0 时使用 DefaultGracePeriod
+	Logger      *zap.Logger   // 用于转发 rclone 日志，为空时丢弃
 }
 
 func NewRcloneClient(opts Options) *Client {
+	logger := opts.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	grace := opts.GracePeriod
+	if grace <= 0 {
+		grace = DefaultGracePeriod
+	}
 	return &Client{
-		RemoteName: opts.RemoteName,
-	}
-}
-
-func (c *Client) Sync(localPath []string, remotePath string) error {
-	for _, lp := range localPath {
-		cmd := exec.Command(
-			"rclone",
-			"sync",
-			lp,
-			fmt.Sprintf("%s:%s", c.RemoteName, remotePath),
-			"--transfers=4",
-			"--checkers=4",
-		)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("rclone sync %s to %s:%s failed: %w", lp, c.RemoteName, remotePath, err)
+		RemoteName:  opts.RemoteName,
+		GracePeriod: grace,
+		logger:      logger.With(zap.String("remote", opts.RemoteName)),
+	}
+}
+
+func (c *Client) remote(path string) string {
+	return fmt.Sprintf("%s:%s", c.RemoteName, path)
+}
+
+const (
+	// 传输过程中 rclone 输出统计的间隔
+	statsInterval = "5s"
+	// rclone 因目录不存在失败时的退出码
+	exitDirNotFound = 3
+	// rclone 因 --max-transfer 停止时的退出码
+	exitMaxTransfer = 8
+	// 其余退出码见 classify.go
+)
+
+// invocation 描述一次 rclone 调用，source 为对应的本地路径，仅用于日志字段
+type invocation struct {
+	source   string
+	target   string
+	progress chan<- Stats
+}
+
+// run 以 JSON 日志模式执行 rclone，逐行转发 stderr 并返回 stdout 与最后一次统计。
+// ctx 取消时先向 rclone 进程组发送 SIGTERM，等待 GracePeriod 后强制终止。
+func (c *Client) run(ctx context.Context, inv invocation, args ...string) ([]byte, Stats, error) {
+	logger := c.logger
+	if inv.source != "" {
+		logger = logger.With(zap.String("source", inv.source))
+	}
+	cmd := exec.CommandContext(ctx, "rclone", append(args, "--use-json-log")...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	}
+	cmd.WaitDelay = c.GracePeriod
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	pr, pw := io.Pipe()
+	cmd.Stderr = pw
+	w := &logWriter{logger: logger, source: inv.source, progress: inv.progress}
+	consumed := make(chan struct{})
+	go func() {
+		defer close(consumed)
+		w.consume(pr)
+	}()
+
+	err := cmd.Run()
+	pw.Close()
+	<-consumed
+	if ctx.Err() != nil {
+		// 清理进程组中可能残留的子进程
+		if cmd.Process != nil {
+			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		}
+		return stdout.Bytes(), w.stats, &Error{Op: args[0], Target: inv.target, Lines: w.errors, Err: ctx.Err()}
+	}
+	if err != nil {
+		code := -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		}
+		if code == exitMaxTransfer {
+			err = fmt.Errorf("%w: %v", ErrMaxTransfer, err)
 		}
+		category := classify(code, w.errors)
+		if code == -1 {
+			// rclone 未能启动（例如未安装）
+			category = CategoryFatal
+		}
+		return stdout.Bytes(), w.stats, &Error{Op: args[0], Target: inv.target, Lines: w.errors, Category: category, Err: err}
+	}
+	return stdout.Bytes(), w.stats, nil
+}
+
+func (c *Client) Sync(ctx context.Context, src, dst string, opts TransferOptions) (Stats, error) {
+	return c.transfer(ctx, "sync", src, dst, opts)
+}
+
+func (c *Client) Copy(ctx context.Context, src, dst string, opts TransferOptions) (Stats, error) {
+	return c.transfer(ctx, "copy", src, dst, opts)
+}
+
+func (c *Client) Restore(ctx context.Context, src, dst string, opts TransferOptions) (Stats, error) {
+	inv := invocation{source: dst, target: c.remote(src) + " -> " + dst, progress: opts.Progress}
+	return c.transferArgs(ctx, inv, "copy", c.remote(src), dst, opts)
+}
+
+func (c *Client) transfer(ctx context.Context, op, src, dst string, opts TransferOptions) (Stats, error) {
+	inv := invocation{source: src, target: src + " -> " + c.remote(dst), progress: opts.Progress}
+	return c.transferArgs(ctx, inv, op, src, c.remote(dst), opts)
+}
+
+func (c *Client) transferArgs(ctx context.Context, inv invocation, op, from, to string, opts TransferOptions) (Stats, error) {
+	args := []string{
+		op,
+		from,
+		to,
+		"--stats=" + statsInterval,
+		"--stats-log-level=NOTICE",
+	}
+	if opts.BackupDir != "" {
+		args = append(args, "--backup-dir="+c.remote(opts.BackupDir))
+	}
+	_, stats, err := c.run(ctx, inv, append(args, transferFlags(opts)...)...)
+	stats.Source = inv.source
+	return stats, err
+}
+
+func (c *Client) List(ctx context.Context, path string, recursive bool) ([]Entry, error) {
+	args := []string{"lsjson", c.remote(path)}
+	if recursive {
+		args = append(args, "-R")
+	}
+	out, _, err := c.run(ctx, invocation{target: c.remote(path)}, args...)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	if err := json.Unmarshal(out, &entries); err != nil {
+		return nil, fmt.Errorf("parse rclone lsjson output: %w", err)
+	}
+	return entries, nil
+}
+
+func (c *Client) Check(ctx context.Context, src, dst string, opts CheckOptions) (*CheckResult, error) {
+	if opts.Compare == CompareModTime {
+		return c.checkModTime(ctx, src, dst, opts)
 	}
-	return nil
-}
-
-func (c *Client) Copy(localPaths []string, remotePath string) error {
-	for _, lp := range localPaths {
-		cmd := exec.Command(
-			"rclone",
-			"copy",
-			lp,
-			fmt.Sprintf("%s:%s", c.RemoteName, remotePath),
-			"--transfers=4",
-			"--checkers=4",
-		)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("rclone copy %s to %s:%s failed: %w", lp, c.RemoteName, remotePath, err)
+	inv := invocation{source: src, target: src + " -> " + c.remote(dst)}
+	args := []string{"check", src, c.remote(dst), "--combined", "-"}
+	if opts.Compare == CompareSize {
+		args = append(args, "--size-only")
+	}
+	out, _, err := c.run(ctx, inv, append(args, filterFlags(opts.Include, opts.Exclude)...)...)
+	// rclone check 在存在差异时以 1 退出，此时 combined 输出仍然有效
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return nil, err
+	}
+	return parseCombined(out), nil
+}
+
+// checkModTime 分别列出两端的文件并比较大小与修改时间，rclone check 本身不支持该方式
+func (c *Client) checkModTime(ctx context.Context, src, dst string, opts CheckOptions) (*CheckResult, error) {
+	srcFiles, err := c.listFiles(ctx, src, src, opts)
+	if err != nil {
+		return nil, err
+	}
+	dstFiles, err := c.listFiles(ctx, src, c.remote(dst), opts)
+	if err != nil {
+		return nil, err
+	}
+	return compareEntries(srcFiles, dstFiles), nil
+}
+
+func (c *Client) listFiles(ctx context.Context, source, target string, opts CheckOptions) (map[string]Entry, error) {
+	args := append([]string{"lsjson", "-R", "--files-only", target}, filterFlags(opts.Include, opts.Exclude)...)
+	out, _, err := c.run(ctx, invocation{source: source, target: target}, args...)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == exitDirNotFound {
+		return map[string]Entry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	if err := json.Unmarshal(out, &entries); err != nil {
+		return nil, fmt.Errorf("parse rclone lsjson output: %w", err)
+	}
+	files := make(map[string]Entry, len(entries))
+	for _, e := range entries {
+		files[e.Path] = e
+	}
+	return files, nil
+}
+
+func (c *Client) Delete(ctx context.Context, path string) error {
+	_, _, err := c.run(ctx, invocation{target: c.remote(path)}, "purge", c.remote(path))
+	return err
+}
+
+func (c *Client) Size(ctx context.Context, path string) (*SizeInfo, error) {
+	out, _, err := c.run(ctx, invocation{target: c.remote(path)}, "size", "--json", c.remote(path))
+	if err != nil {
+		return nil, err
+	}
+	info := &SizeInfo{}
+	if err := json.Unmarshal(out, info); err != nil {
+		return nil, fmt.Errorf("parse rclone size output: %w", err)
+	}
+	return info, nil
+}
+
+func (c *Client) About(ctx context.Context, path string) (*Usage, error) {
+	out, _, err := c.run(ctx, invocation{target: c.remote(path)}, "about", "--json", c.remote(path))
+	if err != nil {
+		return nil, err
+	}
+	usage := &Usage{}
+	if err := json.Unmarshal(out, usage); err != nil {
+		return nil, fmt.Errorf("parse rclone about output: %w", err)
+	}
+	return usage, nil
+}
+
+func transferFlags(opts TransferOptions) []string {
+	transfers, checkers := opts.Transfers, opts.Checkers
+	if transfers == 0 {
+		transfers = 4
+	}
+	if checkers == 0 {
+		checkers = 4
+	}
+	flags := []string{
+		fmt.Sprintf("--transfers=%d", transfers),
+		fmt.Sprintf("--checkers=%d", checkers),
+	}
+	flags = append(flags, filterFlags(opts.Include, opts.Exclude)...)
+	if opts.Checksum {
+		flags = append(flags, "--checksum")
+	}
+	if opts.BwSchedule != "" {
+		// 配置加载时已校验，这里解析失败时退回到固定限速
+		if timetable, err := ParseBwSchedule(opts.BwSchedule); err == nil {
+			flags = append(flags, "--bwlimit="+timetable)
+		}
+	} else if opts.BwLimit != "" {
+		flags = append(flags, "--bwlimit="+opts.BwLimit)
+	}
+	if opts.TPSLimit > 0 {
+		flags = append(flags, "--tpslimit="+strconv.FormatFloat(opts.TPSLimit, 'f', -1, 64))
+	}
+	if opts.DryRun {
+		flags = append(flags, "--dry-run")
+	}
+	if opts.MaxTransfer > 0 {
+		flags = append(flags, fmt.Sprintf("--max-transfer=%dB", opts.MaxTransfer), "--cutoff-mode=soft")
+	}
+	return append(flags, opts.ExtraFlags...)
+}
+
+// filterFlags 生成有序的 --filter 规则：先排除、再包含，有包含规则时排除其余文件，
+// 与本地后端中排除优先的语义一致（混用 --include 与 --exclude 时 rclone 的顺序不确定）
+func filterFlags(include, exclude []string) []string {
+	var flags []string
+	for _, p := range exclude {
+		flags = append(flags, "--filter=- "+p)
+	}
+	for _, p := range include {
+		flags = append(flags, "--filter=+ "+p)
+	}
+	if len(include) > 0 {
+		flags = append(flags, "--filter=- **")
+	}
+	return flags
+}
+
+// parseCombined 解析 rclone check --combined 的输出，每行格式为 "<符号> <路径>"
+func parseCombined(out []byte) *CheckResult {
+	res := &CheckResult{}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		if len(line) < 3 {
+			continue
+		}
+		path := strings.TrimSpace(line[2:])
+		switch line[0] {
+		case '=':
+			res.Same++
+		case '+':
+			res.Missing = append(res.Missing, path)
+		case '-':
+			res.Extra = append(res.Extra, path)
+		case '*', '!':
+			res.Differ = append(res.Differ, path)
 		}
 	}
-	return nil
+	return res
 }
@@ -0,0 +1,76 @@
+package rclone
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFilterFlags(t *testing.T) {
+	tests := []struct {
+		include, exclude []string
+		want             []string
+	}{
+		{nil, nil, nil},
+		{nil, []string{"*.tmp"}, []string{"--filter=- *.tmp"}},
+		{[]string{"*.db"}, nil, []string{"--filter=+ *.db", "--filter=- **"}},
+		// 排除规则在前，tmp/x.db 与本地后端一样被排除
+		{[]string{"*.db", "/conf/**"}, []string{"tmp/**"}, []string{
+			"--filter=- tmp/**", "--filter=+ *.db", "--filter=+ /conf/**", "--filter=- **",
+		}},
+	}
+	for _, tt := range tests {
+		if got := filterFlags(tt.include, tt.exclude); !slices.Equal(got, tt.want) {
+			t.Errorf("filterFlags(%q, %q) = %q, want %q", tt.include, tt.exclude, got, tt.want)
+		}
+	}
+}
+
+func TestTransferFlags(t *testing.T) {
+	got := transferFlags(TransferOptions{
+		Include:    []string{"*.db"},
+		Exclude:    []string{"tmp/**"},
+		Checksum:   true,
+		BwLimit:    "1M",
+		TPSLimit:   2.5,
+		Transfers:  8,
+		ExtraFlags: []string{"--fast-list"},
+	})
+	want := []string{
+		"--transfers=8", "--checkers=4",
+		"--filter=- tmp/**", "--filter=+ *.db", "--filter=- **",
+		"--checksum", "--bwlimit=1M", "--tpslimit=2.5", "--fast-list",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("transferFlags = %q, want %q", got, want)
+	}
+}
+
+func TestParseCombined(t *testing.T) {
+	out := []byte("= same.txt\n" +
+		"= dir/same2.txt\n" +
+		"+ new file.txt\n" +
+		"- old.txt\n" +
+		"* changed.txt\n" +
+		"! error.txt\n" +
+		"\n" +
+		"x\n")
+	res := parseCombined(out)
+	if res.Same != 2 {
+		t.Errorf("Same = %d, want 2", res.Same)
+	}
+	if want := []string{"new file.txt"}; !slices.Equal(res.Missing, want) {
+		t.Errorf("Missing = %q, want %q", res.Missing, want)
+	}
+	if want := []string{"old.txt"}; !slices.Equal(res.Extra, want) {
+		t.Errorf("Extra = %q, want %q", res.Extra, want)
+	}
+	if want := []string{"changed.txt", "error.txt"}; !slices.Equal(res.Differ, want) {
+		t.Errorf("Differ = %q, want %q", res.Differ, want)
+	}
+	if res.Match() {
+		t.Error("Match() = true, want false")
+	}
+	if !parseCombined([]byte("= a\n= b\n")).Match() {
+		t.Error("Match() = false for identical trees")
+	}
+}
@@ -0,0 +1,50 @@
+package rclone
+
+import "testing"
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"*.tmp", "a.tmp", true},
+		{"*.tmp", "dir/a.tmp", true},
+		{"*.tmp", "a.tmp.bak", false},
+		{"/*.tmp", "a.tmp", true},
+		{"/*.tmp", "dir/a.tmp", false},
+		{"cache/*", "cache/a", true},
+		{"cache/*", "x/cache/a", true},
+		{"cache/*", "cache/a/b", false},
+		{"cache/**", "cache/a/b", true},
+		{"/logs/**", "x/logs/a", false},
+		{"?.log", "a.log", true},
+		{"?.log", "ab.log", false},
+		{"a+b.txt", "a+b.txt", true},
+		{"a+b.txt", "aab.txt", false},
+	}
+	for _, tt := range tests {
+		re, err := globToRegexp(tt.pattern)
+		if err != nil {
+			t.Fatalf("globToRegexp(%q): %v", tt.pattern, err)
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("%q matches %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFilterExcludeWins(t *testing.T) {
+	f, err := newFilter([]string{"*.db"}, []string{"tmp/**"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rel, want := range map[string]bool{
+		"data.db":     true,
+		"tmp/data.db": false,
+		"data.txt":    false,
+	} {
+		if got := f.match(rel); got != want {
+			t.Errorf("match(%q) = %v, want %v", rel, got, want)
+		}
+	}
+}
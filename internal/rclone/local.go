@@ -0,0 +1,421 @@
+package rclone
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// Local 是不依赖 rclone 的本地目录后端，可用于备份到挂载的磁盘，
+// 也可以在没有安装 rclone 的环境下完整地运行备份流程。
+type Local struct {
+	Root string
+}
+
+var _ Transport = (*Local)(nil)
+
+func NewLocalTransport(root string) *Local {
+	return &Local{Root: root}
+}
+
+func (l *Local) path(p string) string {
+	if l.Root == "" {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(l.Root, p)
+}
+
+func (l *Local) Sync(ctx context.Context, src, dst string, opts TransferOptions) (Stats, error) {
+	if opts.BackupDir != "" {
+		opts.BackupDir = l.path(opts.BackupDir)
+	}
+	stats, err := l.transfer(ctx, src, l.path(dst), true, opts)
+	if err != nil {
+		return stats, fmt.Errorf("local sync %s to %s failed: %w", src, l.path(dst), err)
+	}
+	return stats, nil
+}
+
+func (l *Local) Copy(ctx context.Context, src, dst string, opts TransferOptions) (Stats, error) {
+	if opts.BackupDir != "" {
+		opts.BackupDir = l.path(opts.BackupDir)
+	}
+	stats, err := l.transfer(ctx, src, l.path(dst), false, opts)
+	if err != nil {
+		return stats, fmt.Errorf("local copy %s to %s failed: %w", src, l.path(dst), err)
+	}
+	return stats, nil
+}
+
+func (l *Local) Restore(ctx context.Context, src, dst string, opts TransferOptions) (Stats, error) {
+	stats, err := l.transfer(ctx, l.path(src), dst, false, opts)
+	if err != nil {
+		return stats, fmt.Errorf("local restore %s to %s failed: %w", l.path(src), dst, err)
+	}
+	return stats, nil
+}
+
+func (l *Local) List(_ context.Context, path string, recursive bool) ([]Entry, error) {
+	root := l.path(path)
+	var entries []Entry
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, p)
+		e := Entry{Path: filepath.ToSlash(rel), ModTime: info.ModTime(), IsDir: d.IsDir()}
+		if !d.IsDir() {
+			e.Size = info.Size()
+		}
+		entries = append(entries, e)
+		if !recursive && d.IsDir() {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("local list %s failed: %w", root, err)
+	}
+	return entries, nil
+}
+
+func (l *Local) Check(_ context.Context, src, dst string, opts CheckOptions) (*CheckResult, error) {
+	srcDir, srcFiles, err := sourceFiles(src)
+	if err != nil {
+		return nil, fmt.Errorf("local check %s failed: %w", src, err)
+	}
+	dstDir := l.path(dst)
+	dstFiles, err := walkFiles(dstDir)
+	if err != nil {
+		return nil, fmt.Errorf("local check %s failed: %w", dstDir, err)
+	}
+	if info, err := os.Stat(src); err == nil && !info.IsDir() {
+		// src 为单个文件时只比较目标目录中的同名文件
+		for rel := range dstFiles {
+			if rel != info.Name() {
+				delete(dstFiles, rel)
+			}
+		}
+	}
+	f, err := newFilter(opts.Include, opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	filterFiles(srcFiles, f)
+	filterFiles(dstFiles, f)
+
+	res := &CheckResult{}
+	for _, rel := range sortedPaths(srcFiles) {
+		s := srcFiles[rel]
+		d, ok := dstFiles[rel]
+		if !ok {
+			res.Missing = append(res.Missing, rel)
+			continue
+		}
+		same := s.Size() == d.Size()
+		switch {
+		case !same:
+		case opts.Compare == CompareModTime:
+			same = d.ModTime().Sub(s.ModTime()).Abs() <= modTimeWindow
+		case opts.Compare != CompareSize:
+			if same, err = sameContent(filepath.Join(srcDir, rel), filepath.Join(dstDir, rel)); err != nil {
+				return nil, err
+			}
+		}
+		if same {
+			res.Same++
+		} else {
+			res.Differ = append(res.Differ, rel)
+		}
+	}
+	for _, rel := range sortedPaths(dstFiles) {
+		if _, ok := srcFiles[rel]; !ok {
+			res.Extra = append(res.Extra, rel)
+		}
+	}
+	return res, nil
+}
+
+func (l *Local) Delete(_ context.Context, path string) error {
+	if err := os.RemoveAll(l.path(path)); err != nil {
+		return fmt.Errorf("local delete %s failed: %w", l.path(path), err)
+	}
+	return nil
+}
+
+func (l *Local) Size(_ context.Context, path string) (*SizeInfo, error) {
+	files, err := walkFiles(l.path(path))
+	if err != nil {
+		return nil, fmt.Errorf("local size %s failed: %w", l.path(path), err)
+	}
+	info := &SizeInfo{}
+	for _, f := range files {
+		info.Count++
+		info.Bytes += f.Size()
+	}
+	return info, nil
+}
+
+// About 返回 path 所在文件系统的容量，path 尚不存在时使用最近的已存在的上级目录
+func (l *Local) About(_ context.Context, path string) (*Usage, error) {
+	p := l.path(path)
+	var st syscall.Statfs_t
+	for {
+		err := syscall.Statfs(p, &st)
+		if err == nil {
+			break
+		}
+		parent := filepath.Dir(p)
+		if !errors.Is(err, fs.ErrNotExist) || parent == p {
+			return nil, fmt.Errorf("local about %s failed: %w", l.path(path), err)
+		}
+		p = parent
+	}
+	bsize := int64(st.Bsize)
+	total := int64(st.Blocks) * bsize
+	free := int64(st.Bavail) * bsize
+	used := int64(st.Blocks-st.Bfree) * bsize
+	return &Usage{Total: &total, Used: &used, Free: &free}, nil
+}
+
+// transfer 与 rclone 的语义保持一致：src 为目录时复制其内容，src 为文件时复制到 dst 目录下
+func (l *Local) transfer(ctx context.Context, src, dst string, deleteExtra bool, opts TransferOptions) (Stats, error) {
+	start := time.Now()
+	stats := Stats{Source: src}
+	info, err := os.Stat(src)
+	if err != nil {
+		return stats, err
+	}
+	src, srcFiles, err := sourceFiles(src)
+	if err != nil {
+		return stats, err
+	}
+	f, err := newFilter(opts.Include, opts.Exclude)
+	if err != nil {
+		return stats, err
+	}
+	filterFiles(srcFiles, f)
+	stats.TotalChecks = int64(len(srcFiles))
+	for _, f := range srcFiles {
+		stats.TotalBytes += f.Size()
+	}
+	for _, rel := range sortedPaths(srcFiles) {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		fi := srcFiles[rel]
+		from, to := filepath.Join(src, rel), filepath.Join(dst, rel)
+		changed, err := fileChanged(from, to, fi, opts.Checksum)
+		if err != nil {
+			stats.Errors++
+			return stats, err
+		}
+		stats.Checks++
+		if changed {
+			if opts.MaxTransfer > 0 && stats.Bytes+fi.Size() > opts.MaxTransfer {
+				return stats, ErrMaxTransfer
+			}
+			if !opts.DryRun {
+				if err := archiveFile(to, opts.BackupDir, rel); err != nil {
+					stats.Errors++
+					return stats, err
+				}
+				if err := copyFile(from, to, fi); err != nil {
+					stats.Errors++
+					return stats, err
+				}
+			}
+			stats.Transfers++
+			stats.TotalTransfers++
+			stats.Bytes += fi.Size()
+		}
+		stats.ElapsedTime = time.Since(start).Seconds()
+		if stats.ElapsedTime > 0 {
+			stats.Speed = float64(stats.Bytes) / stats.ElapsedTime
+		}
+		sendProgress(opts.Progress, stats)
+	}
+	if !deleteExtra || !info.IsDir() {
+		return stats, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return stats, err
+	}
+	dstFiles, err := walkFiles(dst)
+	if err != nil {
+		return stats, err
+	}
+	// 与 rclone 一致，被过滤掉的目标文件不会被删除
+	filterFiles(dstFiles, f)
+	for rel := range dstFiles {
+		if _, ok := srcFiles[rel]; !ok {
+			if opts.DryRun {
+				stats.Deletes++
+				continue
+			}
+			target := filepath.Join(dst, rel)
+			if err := archiveFile(target, opts.BackupDir, rel); err != nil {
+				stats.Errors++
+				return stats, err
+			}
+			if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				stats.Errors++
+				return stats, err
+			}
+			stats.Deletes++
+		}
+	}
+	return stats, nil
+}
+
+// sourceFiles 返回 src 中需要传输的文件及其所在目录，src 为单个文件时目录为其父目录
+func sourceFiles(src string) (string, map[string]fs.FileInfo, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return "", nil, err
+	}
+	if !info.IsDir() {
+		return filepath.Dir(src), map[string]fs.FileInfo{info.Name(): info}, nil
+	}
+	files, err := walkFiles(src)
+	return src, files, err
+}
+
+// walkFiles 返回 root 下所有普通文件，键为以 / 分隔的相对路径；root 不存在时返回空结果
+func walkFiles(root string) (map[string]fs.FileInfo, error) {
+	files := make(map[string]fs.FileInfo)
+	info, err := os.Stat(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return files, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		files[info.Name()] = info
+		return files, nil
+	}
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, p)
+		files[filepath.ToSlash(rel)] = fi
+		return nil
+	})
+	return files, err
+}
+
+func filterFiles(files map[string]fs.FileInfo, f *filter) {
+	for rel := range files {
+		if !f.match(rel) {
+			delete(files, rel)
+		}
+	}
+}
+
+// fileChanged 判断 dst 是否需要更新：默认比较大小与修改时间，checksum 为 true 时比较大小与内容哈希
+func fileChanged(src, dst string, info fs.FileInfo, checksum bool) (bool, error) {
+	cur, err := os.Stat(dst)
+	if err != nil || cur.Size() != info.Size() {
+		return true, nil
+	}
+	if !checksum {
+		return !cur.ModTime().Equal(info.ModTime()), nil
+	}
+	same, err := sameContent(src, dst)
+	return !same, err
+}
+
+// archiveFile 在 backupDir 非空且 target 存在时，把 target 移动到 backupDir/rel
+func archiveFile(target, backupDir, rel string) error {
+	if backupDir == "" {
+		return nil
+	}
+	if _, err := os.Stat(target); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	archived := filepath.Join(backupDir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(archived), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(target, archived)
+}
+
+func copyFile(src, dst string, info fs.FileInfo) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".hermes-tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chtimes(tmp, info.ModTime(), info.ModTime()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+func sameContent(a, b string) (bool, error) {
+	ha, err := fileHash(a)
+	if err != nil {
+		return false, err
+	}
+	hb, err := fileHash(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(ha, hb), nil
+}
+
+func fileHash(name string) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,144 @@
+package rclone
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func treeFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := walkFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sortedPaths(files)
+}
+
+func TestLocalCopyAndSync(t *testing.T) {
+	src, root := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{"a": "1", "dir/b": "22"})
+	l := NewLocalTransport(root)
+	ctx := context.Background()
+
+	stats, err := l.Copy(ctx, src, "bucket/data", TransferOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Transfers != 2 || stats.Bytes != 3 {
+		t.Errorf("first copy: transfers = %d, bytes = %d, want 2 and 3", stats.Transfers, stats.Bytes)
+	}
+	if stats, _ = l.Copy(ctx, src, "bucket/data", TransferOptions{}); stats.Transfers != 0 {
+		t.Errorf("unchanged copy transferred %d files", stats.Transfers)
+	}
+
+	// copy 保留目标端多余的文件，sync 删除它们
+	if err := os.Remove(filepath.Join(src, "a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Copy(ctx, src, "bucket/data", TransferOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := treeFiles(t, filepath.Join(root, "bucket/data")); !slices.Equal(got, []string{"a", "dir/b"}) {
+		t.Errorf("after copy: %q", got)
+	}
+	stats, err = l.Sync(ctx, src, "bucket/data", TransferOptions{DryRun: true})
+	if err != nil || stats.Deletes != 1 {
+		t.Fatalf("dry-run sync: deletes = %d, err = %v, want 1 deletion", stats.Deletes, err)
+	}
+	if got := treeFiles(t, filepath.Join(root, "bucket/data")); len(got) != 2 {
+		t.Errorf("dry-run sync changed the destination: %q", got)
+	}
+	if stats, err = l.Sync(ctx, src, "bucket/data", TransferOptions{}); err != nil || stats.Deletes != 1 {
+		t.Fatalf("sync: deletes = %d, err = %v, want 1 deletion", stats.Deletes, err)
+	}
+	if got := treeFiles(t, filepath.Join(root, "bucket/data")); !slices.Equal(got, []string{"dir/b"}) {
+		t.Errorf("after sync: %q", got)
+	}
+}
+
+func TestLocalSingleFileSource(t *testing.T) {
+	dir, root := t.TempDir(), t.TempDir()
+	writeTree(t, dir, map[string]string{"app.conf": "x", "other": "y"})
+	l := NewLocalTransport(root)
+	if _, err := l.Sync(context.Background(), filepath.Join(dir, "app.conf"), "conf", TransferOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := treeFiles(t, filepath.Join(root, "conf")); !slices.Equal(got, []string{"app.conf"}) {
+		t.Errorf("destination = %q, want only app.conf", got)
+	}
+}
+
+func TestLocalMaxTransfer(t *testing.T) {
+	src, root := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{"a": "1234", "b": "5678"})
+	stats, err := NewLocalTransport(root).Copy(context.Background(), src, "dst", TransferOptions{MaxTransfer: 6})
+	if !errors.Is(err, ErrMaxTransfer) {
+		t.Fatalf("err = %v, want ErrMaxTransfer", err)
+	}
+	if stats.Bytes != 4 {
+		t.Errorf("bytes = %d, want 4", stats.Bytes)
+	}
+}
+
+func TestLocalCheckAndRestore(t *testing.T) {
+	src, root, target := t.TempDir(), t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{"same": "1", "changed": "2", "new": "3"})
+	writeTree(t, filepath.Join(root, "dst"), map[string]string{"same": "1", "changed": "22", "gone": "4"})
+	l := NewLocalTransport(root)
+
+	res, err := l.Check(context.Background(), src, "dst", CheckOptions{Compare: CompareHash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Same != 1 || !slices.Equal(res.Missing, []string{"new"}) ||
+		!slices.Equal(res.Extra, []string{"gone"}) || !slices.Equal(res.Differ, []string{"changed"}) {
+		t.Errorf("check = %+v", res)
+	}
+
+	if _, err := l.Restore(context.Background(), "dst", target, TransferOptions{Include: []string{"same", "gone"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := treeFiles(t, target); !slices.Equal(got, []string{"gone", "same"}) {
+		t.Errorf("restored %q, want gone and same", got)
+	}
+}
+
+func TestLocalProgress(t *testing.T) {
+	src, root := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{"a": "1", "b": "22", "c": "333"})
+	progress := make(chan Stats, 8)
+	stats, err := NewLocalTransport(root).Copy(context.Background(), src, "dst", TransferOptions{Progress: progress})
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(progress)
+	var last Stats
+	n := 0
+	for s := range progress {
+		last = s
+		n++
+	}
+	if n != 3 || last.Bytes != 6 || last.TotalBytes != 6 || last.Transfers != 3 {
+		t.Errorf("%d progress updates, last = %+v", n, last)
+	}
+	if stats.Bytes != last.Bytes {
+		t.Errorf("result bytes = %d, last progress = %d", stats.Bytes, last.Bytes)
+	}
+}
@@ -0,0 +1,115 @@
+package rclone
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 失败时附加到错误上的 rclone 错误日志行数
+const maxErrorLines = 20
+
+// Error 表示一次失败的 rclone 调用，Lines 保存最近的错误日志
+type Error struct {
+	Op       string
+	Target   string
+	Lines    []string
+	Category Category // 由退出码与错误日志确定，取消时为空
+	Err      error
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("rclone %s %s failed: %v", e.Op, e.Target, e.Err)
+	if len(e.Lines) > 0 {
+		msg += ": " + strings.Join(e.Lines, "; ")
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is 使 errors.Is(err, ErrTransient) 等按分类匹配
+func (e *Error) Is(target error) bool {
+	return e.Category != "" && categoryErrors[e.Category] == target
+}
+
+// logEntry 对应 rclone --use-json-log 输出的一行
+type logEntry struct {
+	Level  string `json:"level"`
+	Msg    string `json:"msg"`
+	Object string `json:"object"`
+	Stats  *Stats `json:"stats"`
+}
+
+// logWriter 逐行读取 rclone 的 stderr，转发到 zap 并保留最近的错误行，
+// 带有 stats 字段的行作为进度发送到 progress
+type logWriter struct {
+	logger   *zap.Logger
+	source   string
+	progress chan<- Stats
+	stats    Stats
+	errors   []string
+}
+
+func (w *logWriter) consume(r io.Reader) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		w.handle(sc.Text())
+	}
+}
+
+func (w *logWriter) handle(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	var e logEntry
+	if err := json.Unmarshal([]byte(line), &e); err != nil || e.Msg == "" {
+		// 非 JSON 的输出（例如参数错误）按错误处理
+		e = logEntry{Level: "error", Msg: line}
+	}
+	if e.Stats != nil {
+		w.stats = *e.Stats
+		w.stats.Source = w.source
+		sendProgress(w.progress, w.stats)
+		return
+	}
+	level := parseLevel(e.Level)
+	fields := []zap.Field{zap.String("rclone_level", e.Level)}
+	if e.Object != "" {
+		fields = append(fields, zap.String("object", e.Object))
+	}
+	w.logger.Log(level, e.Msg, fields...)
+
+	if level >= zapcore.ErrorLevel {
+		msg := e.Msg
+		if e.Object != "" {
+			msg = e.Object + ": " + msg
+		}
+		w.errors = append(w.errors, msg)
+		if len(w.errors) > maxErrorLines {
+			w.errors = w.errors[len(w.errors)-maxErrorLines:]
+		}
+	}
+}
+
+func parseLevel(level string) zapcore.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return zapcore.DebugLevel
+	case "warning", "warn":
+		return zapcore.WarnLevel
+	case "error", "critical", "alert", "emergency":
+		return zapcore.ErrorLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
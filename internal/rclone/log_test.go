@@ -0,0 +1,88 @@
+package rclone
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogWriterFields(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	w := &logWriter{logger: zap.New(core)}
+	w.consume(strings.NewReader(`{"level":"info","msg":"Copied (new)","object":"a.txt"}
+{"level":"warning","msg":"slow down"}
+
+{"level":"error","msg":"Failed to copy: 403 Forbidden","object":"b.txt"}
+Usage: rclone sync source:path dest:path
+`))
+
+	entries := logs.All()
+	if len(entries) != 4 {
+		t.Fatalf("logged %d entries, want 4", len(entries))
+	}
+	want := []struct {
+		level  zapcore.Level
+		msg    string
+		object string
+	}{
+		{zapcore.InfoLevel, "Copied (new)", "a.txt"},
+		{zapcore.WarnLevel, "slow down", ""},
+		{zapcore.ErrorLevel, "Failed to copy: 403 Forbidden", "b.txt"},
+		{zapcore.ErrorLevel, "Usage: rclone sync source:path dest:path", ""},
+	}
+	for i, e := range entries {
+		if e.Level != want[i].level || e.Message != want[i].msg {
+			t.Errorf("entry %d = %s %q, want %s %q", i, e.Level, e.Message, want[i].level, want[i].msg)
+		}
+		if got, _ := e.ContextMap()["object"].(string); got != want[i].object {
+			t.Errorf("entry %d object = %q, want %q", i, got, want[i].object)
+		}
+	}
+	// 非 JSON 的输出也作为错误保留，附加到失败的错误上
+	if wantErrs := []string{"b.txt: Failed to copy: 403 Forbidden", "Usage: rclone sync source:path dest:path"}; !slices.Equal(w.errors, wantErrs) {
+		t.Errorf("errors = %q, want %q", w.errors, wantErrs)
+	}
+}
+
+func TestLogWriterKeepsLastErrors(t *testing.T) {
+	w := &logWriter{logger: zap.NewNop()}
+	for i := range maxErrorLines + 5 {
+		w.handle(fmt.Sprintf(`{"level":"error","msg":"error %d"}`, i))
+	}
+	if len(w.errors) != maxErrorLines || w.errors[0] != "error 5" {
+		t.Errorf("kept %d errors starting with %q, want %d starting with \"error 5\"", len(w.errors), w.errors[0], maxErrorLines)
+	}
+}
+
+func TestLogWriterProgress(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	progress := make(chan Stats, 4)
+	w := &logWriter{logger: zap.New(core), source: "/data", progress: progress}
+	w.consume(strings.NewReader(`{"level":"info","msg":"stats","stats":{"bytes":100,"totalBytes":400,"transfers":1,"speed":50,"eta":6}}
+{"level":"info","msg":"stats","stats":{"bytes":400,"totalBytes":400,"transfers":4,"speed":80}}
+`))
+	close(progress)
+
+	var got []Stats
+	for s := range progress {
+		got = append(got, s)
+	}
+	if len(got) != 2 || got[0].Bytes != 100 || got[0].ETA != 6 || got[1].Transfers != 4 {
+		t.Fatalf("progress = %+v", got)
+	}
+	if got[0].Source != "/data" {
+		t.Errorf("source = %q, want /data", got[0].Source)
+	}
+	// 最后一次统计作为本次传输的结果，统计行不写入日志
+	if w.stats.Bytes != 400 || w.stats.Speed != 80 {
+		t.Errorf("final stats = %+v", w.stats)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("stats lines were logged: %v", logs.All())
+	}
+}
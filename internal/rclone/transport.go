@@ -0,0 +1,170 @@
+package rclone
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrMaxTransfer 表示传输因达到 TransferOptions.MaxTransfer 而提前停止
+var ErrMaxTransfer = errors.New("max transfer limit reached")
+
+// Transport 抽象了备份使用的传输后端。src 为本地路径，dst/path 为后端内的路径
+// （对 rclone 而言是 remote 下的 bucket/路径，对本地后端而言是目录）。
+type Transport interface {
+	Sync(ctx context.Context, src, dst string, opts TransferOptions) (Stats, error)
+	Copy(ctx context.Context, src, dst string, opts TransferOptions) (Stats, error)
+	// Restore 与 Copy 方向相反：把后端中的 src 复制到本地目录 dst
+	Restore(ctx context.Context, src, dst string, opts TransferOptions) (Stats, error)
+	List(ctx context.Context, path string, recursive bool) ([]Entry, error)
+	Check(ctx context.Context, src, dst string, opts CheckOptions) (*CheckResult, error)
+	Delete(ctx context.Context, path string) error
+	Size(ctx context.Context, path string) (*SizeInfo, error)
+	// About 返回 path 所在存储的容量信息，后端不支持的字段为 nil
+	About(ctx context.Context, path string) (*Usage, error)
+}
+
+// TransferOptions 是单次 Sync/Copy 调用的参数
+type TransferOptions struct {
+	// Progress 接收传输过程中的周期性统计，发送不会阻塞，消费不及时的统计会被丢弃
+	Progress chan<- Stats
+
+	Include    []string
+	Exclude    []string
+	Checksum   bool // 用哈希代替修改时间判断文件是否变化
+	BwLimit    string
+	BwSchedule string // "08:00 512k, 23:00 off"，设置后优先于 BwLimit
+	TPSLimit   float64
+	Transfers  int // 为 0 时使用 4
+	Checkers   int // 为 0 时使用 4
+	ExtraFlags []string
+
+	// MaxTransfer 大于 0 时限制本次传输的字节数，达到上限后返回 ErrMaxTransfer
+	MaxTransfer int64
+	// DryRun 为 true 时只统计将要执行的操作而不修改任何文件
+	DryRun bool
+	// BackupDir 非空时，被覆盖或删除的目标文件移动到该目录（与 dst 位于同一后端）而不是直接删除
+	BackupDir string
+}
+
+// Stats 对应 rclone --stats 输出的统计信息
+type Stats struct {
+	Source         string  `json:"source"`
+	Bytes          int64   `json:"bytes"`
+	TotalBytes     int64   `json:"totalBytes"`
+	Checks         int64   `json:"checks"`
+	TotalChecks    int64   `json:"totalChecks"`
+	Transfers      int64   `json:"transfers"`
+	TotalTransfers int64   `json:"totalTransfers"`
+	Deletes        int64   `json:"deletes"`
+	Errors         int64   `json:"errors"`
+	Speed          float64 `json:"speed"`       // bytes/s
+	ETA            float64 `json:"eta"`         // 秒，0 表示未知
+	ElapsedTime    float64 `json:"elapsedTime"` // 秒
+}
+
+// Add 累加另一次传输的统计，用于汇总多个源路径
+func (s *Stats) Add(o Stats) {
+	s.Bytes += o.Bytes
+	s.TotalBytes += o.TotalBytes
+	s.Checks += o.Checks
+	s.TotalChecks += o.TotalChecks
+	s.Transfers += o.Transfers
+	s.TotalTransfers += o.TotalTransfers
+	s.Deletes += o.Deletes
+	s.Errors += o.Errors
+	s.ElapsedTime += o.ElapsedTime
+}
+
+func sendProgress(ch chan<- Stats, s Stats) {
+	if ch == nil {
+		return
+	}
+	select {
+	case ch <- s:
+	default:
+	}
+}
+
+type Entry struct {
+	Path    string    `json:"Path"`
+	Size    int64     `json:"Size"`
+	ModTime time.Time `json:"ModTime"`
+	IsDir   bool      `json:"IsDir"`
+}
+
+type SizeInfo struct {
+	Count int64 `json:"count"`
+	Bytes int64 `json:"bytes"`
+}
+
+// Usage 对应 rclone about --json 的输出，单位为字节
+type Usage struct {
+	Total   *int64 `json:"total,omitempty"`
+	Used    *int64 `json:"used,omitempty"`
+	Free    *int64 `json:"free,omitempty"`
+	Trashed *int64 `json:"trashed,omitempty"`
+	Other   *int64 `json:"other,omitempty"`
+	Objects *int64 `json:"objects,omitempty"`
+}
+
+// Check 的比较方式
+const (
+	CompareSize    = "size"    // 仅比较大小
+	CompareModTime = "modtime" // 比较大小与修改时间，与 sync/copy 判断变化的方式一致
+	CompareHash    = "hash"    // 比较大小与哈希
+)
+
+type CheckOptions struct {
+	Include []string
+	Exclude []string
+	Compare string // 为空时使用 CompareHash
+}
+
+// CheckResult 记录 src 与 dst 的差异，路径均相对于比较的根目录
+type CheckResult struct {
+	Same    int      `json:"same"`
+	Missing []string `json:"missing"` // 仅存在于 src
+	Extra   []string `json:"extra"`   // 仅存在于 dst
+	Differ  []string `json:"differ"`
+}
+
+func (r *CheckResult) Match() bool {
+	return len(r.Missing) == 0 && len(r.Extra) == 0 && len(r.Differ) == 0
+}
+
+// 修改时间的比较精度，部分后端只保存到秒
+const modTimeWindow = time.Second
+
+// compareEntries 按大小与修改时间比较两端的文件列表
+func compareEntries(src, dst map[string]Entry) *CheckResult {
+	res := &CheckResult{}
+	for _, rel := range sortedPaths(src) {
+		s := src[rel]
+		d, ok := dst[rel]
+		switch {
+		case !ok:
+			res.Missing = append(res.Missing, rel)
+		case d.Size != s.Size || d.ModTime.Sub(s.ModTime).Abs() > modTimeWindow:
+			res.Differ = append(res.Differ, rel)
+		default:
+			res.Same++
+		}
+	}
+	for _, rel := range sortedPaths(dst) {
+		if _, ok := src[rel]; !ok {
+			res.Extra = append(res.Extra, rel)
+		}
+	}
+	return res
+}
+
+func sortedPaths[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}